@@ -0,0 +1,201 @@
+package clienttracing
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/configauth"
+	"go.opentelemetry.io/collector/config/configopaque"
+	"go.opentelemetry.io/collector/config/configoptional"
+	"go.opentelemetry.io/collector/extension/extensionauth"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	authBasic  = "basic"
+	authBearer = "bearer"
+	authOAuth2 = "oauth2"
+
+	defaultTokenRefreshInterval = time.Minute
+	tokenRequestTimeout         = 10 * time.Second
+)
+
+// authExtensionID identifies the authenticator that adds refreshed tokens to the requests of the exporters.
+var authExtensionID = component.NewID(component.MustNewType("k6_auth"))
+
+// AuthenticationConfig configures the credentials that are sent with every push.
+type AuthenticationConfig struct {
+	// Type the authentication scheme: "basic", "bearer" or "oauth2" (default: "basic" if a user or password is set)
+	Type string `js:"type"`
+	// User the user for basic authentication
+	User string `js:"user"`
+	// Password the password for basic authentication
+	Password string `js:"password"`
+	// Token a static bearer token
+	Token string `js:"token"`
+	// TokenFile a file containing the bearer token, the file is re-read every RefreshInterval
+	TokenFile string `js:"token_file"`
+	// RefreshInterval how often the token file is re-read (default: "1m")
+	RefreshInterval string `js:"refresh_interval"`
+	// ClientID the client id of the OAuth2 client credentials flow
+	ClientID string `js:"client_id"`
+	// ClientSecret the client secret of the OAuth2 client credentials flow
+	ClientSecret string `js:"client_secret"`
+	// TokenURL the token endpoint of the OAuth2 client credentials flow
+	TokenURL string `js:"token_url"`
+	// Scopes the scopes requested by the OAuth2 client credentials flow (optional)
+	Scopes []string `js:"scopes"`
+}
+
+// clientAuth contains either a static Authorization header or a source of tokens that are refreshed while the
+// client is used. Both are empty if no authentication is configured.
+type clientAuth struct {
+	header configopaque.String
+	source oauth2.TokenSource
+}
+
+func newClientAuth(cfg *AuthenticationConfig) (*clientAuth, error) {
+	authType := cfg.Type
+	if authType == "" && (cfg.User != "" || cfg.Password != "") {
+		authType = authBasic
+	}
+
+	switch authType {
+	case "":
+		return &clientAuth{}, nil
+	case authBasic:
+		if cfg.User == "" && cfg.Password == "" {
+			return nil, errors.New("basic authentication requires a user or password")
+		}
+		return &clientAuth{header: basicAuthorization(cfg.User, cfg.Password)}, nil
+	case authBearer:
+		if (cfg.Token == "") == (cfg.TokenFile == "") {
+			return nil, errors.New("bearer authentication requires either a token or a token_file")
+		}
+		if cfg.Token != "" {
+			return &clientAuth{header: configopaque.String("Bearer " + cfg.Token)}, nil
+		}
+		interval := defaultTokenRefreshInterval
+		if err := parseDuration(cfg.RefreshInterval, &interval); err != nil {
+			return nil, fmt.Errorf("invalid refresh_interval: %w", err)
+		}
+		source := &fileTokenSource{path: cfg.TokenFile, interval: interval}
+		// fail early if the token file can't be read
+		if _, err := source.Token(); err != nil {
+			return nil, err
+		}
+		return &clientAuth{source: source}, nil
+	case authOAuth2:
+		if cfg.ClientID == "" || cfg.ClientSecret == "" || cfg.TokenURL == "" {
+			return nil, errors.New("oauth2 authentication requires a client_id, client_secret and token_url")
+		}
+		ccCfg := clientcredentials.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			TokenURL:     cfg.TokenURL,
+			Scopes:       cfg.Scopes,
+		}
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Timeout: tokenRequestTimeout})
+		// the token source caches the token and requests a new one shortly before it expires
+		return &clientAuth{source: ccCfg.TokenSource(ctx)}, nil
+	default:
+		return nil, fmt.Errorf("unknown authentication type %s", authType)
+	}
+}
+
+// config returns the authenticator configuration of the exporter clients, which is only set for refreshed tokens.
+func (a *clientAuth) config() configoptional.Optional[configauth.Config] {
+	if a.source == nil {
+		return configoptional.None[configauth.Config]()
+	}
+	return configoptional.Some(configauth.Config{AuthenticatorID: authExtensionID})
+}
+
+func basicAuthorization(user, password string) configopaque.String {
+	return configopaque.String("Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password)))
+}
+
+// fileTokenSource reads a bearer token from a file, e.g. a Kubernetes service account token. The file is re-read
+// once the token is older than interval, so rotated tokens are picked up.
+type fileTokenSource struct {
+	path     string
+	interval time.Duration
+
+	mu    sync.Mutex
+	token *oauth2.Token
+	read  time.Time
+}
+
+func (s *fileTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != nil && time.Since(s.read) < s.interval {
+		return s.token, nil
+	}
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token file: %w", err)
+	}
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return nil, fmt.Errorf("token file %s is empty", s.path)
+	}
+	s.token = &oauth2.Token{AccessToken: token, TokenType: "Bearer"}
+	s.read = time.Now()
+	return s.token, nil
+}
+
+// tokenAuthenticator is the authenticator of the HTTP and gRPC clients of the exporters, it sets the Authorization
+// header to the current token of the source.
+type tokenAuthenticator struct {
+	source oauth2.TokenSource
+}
+
+var (
+	_ extensionauth.HTTPClient = tokenAuthenticator{}
+	_ extensionauth.GRPCClient = tokenAuthenticator{}
+)
+
+func (tokenAuthenticator) Start(context.Context, component.Host) error {
+	return nil
+}
+
+func (tokenAuthenticator) Shutdown(context.Context) error {
+	return nil
+}
+
+func (a tokenAuthenticator) RoundTripper(base http.RoundTripper) (http.RoundTripper, error) {
+	return &oauth2.Transport{Source: a.source, Base: base}, nil
+}
+
+func (a tokenAuthenticator) PerRPCCredentials() (credentials.PerRPCCredentials, error) {
+	return tokenCredentials(a), nil
+}
+
+type tokenCredentials struct {
+	source oauth2.TokenSource
+}
+
+func (c tokenCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	token, err := c.source.Token()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"authorization": token.Type() + " " + token.AccessToken}, nil
+}
+
+// RequireTransportSecurity returns false, the token is sent on insecure connections like any other header.
+func (tokenCredentials) RequireTransportSecurity() bool {
+	return false
+}
@@ -0,0 +1,167 @@
+package clienttracing
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/config/configopaque"
+)
+
+func TestNewClientAuth(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("file-token\n"), 0o600))
+
+	for name, tc := range map[string]struct {
+		cfg     AuthenticationConfig
+		header  configopaque.String
+		refresh bool
+	}{
+		"none":         {},
+		"basic":        {cfg: AuthenticationConfig{User: "user", Password: "pass"}, header: "Basic dXNlcjpwYXNz"},
+		"basic token":  {cfg: AuthenticationConfig{Type: authBasic, Password: "pass"}, header: "Basic OnBhc3M="},
+		"bearer token": {cfg: AuthenticationConfig{Type: authBearer, Token: "secret"}, header: "Bearer secret"},
+		"bearer file":  {cfg: AuthenticationConfig{Type: authBearer, TokenFile: tokenFile}, refresh: true},
+		"oauth2": {
+			cfg:     AuthenticationConfig{Type: authOAuth2, ClientID: "id", ClientSecret: "secret", TokenURL: "http://localhost/token"},
+			refresh: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			auth, err := newClientAuth(&tc.cfg)
+			require.NoError(t, err)
+			assert.Equal(t, tc.header, auth.header)
+			assert.Equal(t, tc.refresh, auth.source != nil)
+			assert.Equal(t, tc.refresh, auth.config().HasValue())
+		})
+	}
+}
+
+func TestNewClientAuthInvalid(t *testing.T) {
+	for name, cfg := range map[string]AuthenticationConfig{
+		"unknown type":      {Type: "digest"},
+		"basic":             {Type: authBasic},
+		"bearer":            {Type: authBearer},
+		"bearer both":       {Type: authBearer, Token: "secret", TokenFile: "token"},
+		"bearer file":       {Type: authBearer, TokenFile: filepath.Join(t.TempDir(), "missing")},
+		"bearer interval":   {Type: authBearer, TokenFile: "token", RefreshInterval: "often"},
+		"oauth2 incomplete": {Type: authOAuth2, ClientID: "id"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := newClientAuth(&cfg)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestBuildHeaders(t *testing.T) {
+	cfg := &ClientConfig{Headers: map[string]configopaque.String{"X-Scope-OrgID": "tenant"}}
+
+	// no empty Authorization header is sent without credentials
+	assert.Equal(t, configopaque.MapList{{Name: "X-Scope-OrgID", Value: "tenant"}}, buildHeaders(cfg, &clientAuth{}))
+
+	headers := buildHeaders(cfg, &clientAuth{header: "Bearer secret"})
+	value, ok := headers.Get("Authorization")
+	assert.True(t, ok)
+	assert.Equal(t, configopaque.String("Bearer secret"), value)
+}
+
+func TestFileTokenSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("first"), 0o600))
+
+	cached := &fileTokenSource{path: path, interval: defaultTokenRefreshInterval}
+	reread := &fileTokenSource{path: path}
+	for _, s := range []*fileTokenSource{cached, reread} {
+		token, err := s.Token()
+		require.NoError(t, err)
+		assert.Equal(t, "first", token.AccessToken)
+	}
+
+	require.NoError(t, os.WriteFile(path, []byte("second"), 0o600))
+	token, err := cached.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "first", token.AccessToken)
+	token, err = reread.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "second", token.AccessToken)
+}
+
+func TestClient_OAuth2(t *testing.T) {
+	var tokenRequests atomic.Int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests.Add(1)
+		if err := r.ParseForm(); err != nil || r.Form.Get("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if id, secret, _ := r.BasicAuth(); id != "k6" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "oauth-token", "token_type": "Bearer", "expires_in": 3600})
+	}))
+	defer tokenServer.Close()
+
+	var (
+		mu             sync.Mutex
+		authorizations []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client := newHeadersTestClient(t, &ClientConfig{
+		Exporter: exporterOTLPHTTP,
+		Endpoint: srv.URL,
+		Authentication: AuthenticationConfig{
+			Type:         authOAuth2,
+			ClientID:     "k6",
+			ClientSecret: "secret",
+			TokenURL:     tokenServer.URL,
+		},
+	})
+
+	traces := testPushTraces()
+	require.NoError(t, client.Push(traces, nil))
+	require.NoError(t, client.Push(traces, nil))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"Bearer oauth-token", "Bearer oauth-token"}, authorizations)
+	// the token is cached until it expires
+	assert.Equal(t, int32(1), tokenRequests.Load())
+}
+
+func TestClient_BearerTokenFileGRPC(t *testing.T) {
+	receiver := startMockReceiver(t)
+	endpoint := receiver.GRPCEndpoint()
+
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("first"), 0o600))
+
+	client := newHeadersTestClient(t, &ClientConfig{
+		Endpoint:       endpoint,
+		Authentication: AuthenticationConfig{Type: authBearer, TokenFile: path, RefreshInterval: "0s"},
+	})
+
+	traces := testPushTraces()
+	require.NoError(t, client.Push(traces, nil))
+	require.NoError(t, os.WriteFile(path, []byte("second"), 0o600))
+	require.NoError(t, client.Push(traces, nil))
+
+	assert.Equal(t, []string{"Bearer first", "Bearer second"}, receiver.HeaderValues("authorization"))
+}
@@ -18,18 +18,14 @@ const client = new tracing.Client({
 
 export default function () {
     let pushSizeTraces = randomIntBetween(2, 3);
-    let pushSizeSpans = 0;
     let t = [];
     for (let i = 0; i < pushSizeTraces; i++) {
-        let c = randomIntBetween(5, 10)
-        pushSizeSpans += c;
-
         t.push({
             random_service_name: false,
             count: 1,
             resource_size: 100,
             spans: {
-                count: c,
+                count: randomIntBetween(5, 10),
                 size: randomIntBetween(300, 1000),
                 random_name: true,
                 fixed_attrs: {
@@ -43,7 +39,8 @@ export default function () {
     let traces = gen.traces()
     client.push(traces);
 
-    console.log(`Pushed ${pushSizeSpans} spans from ${pushSizeTraces} different traces. Here is a random traceID: ${t[Math.floor(Math.random() * t.length)].id}`);
+    let traceIds = traces.traceIds();
+    console.log(`Pushed ${traces.spanCount()} spans from ${traceIds.length} different traces. Here is a random traceID: ${traceIds[Math.floor(Math.random() * traceIds.length)]}`);
     sleep(15);
 }
 
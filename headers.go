@@ -0,0 +1,224 @@
+package clienttracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync/atomic"
+
+	"go.k6.io/k6/v2/js/modules"
+	"go.opentelemetry.io/collector/client"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/configmiddleware"
+	"go.opentelemetry.io/collector/extension/extensionmiddleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/grafana/xk6-client-tracing/pkg/random"
+)
+
+const defaultTenantHeader = "X-Scope-OrgID"
+
+// headersExtensionID identifies the middleware that adds the headers of a push to the requests of the exporters.
+var headersExtensionID = component.NewID(component.MustNewType("k6_push_headers"))
+
+// PushOptions contains the options that can be passed as second argument to push and pushAsync.
+type PushOptions struct {
+	// Headers that are sent with this push in addition to the headers of the client, headers with the same name
+	// replace the headers of the client
+	Headers map[string]string `js:"headers"`
+}
+
+// TenantConfig configures a rotation of tenants. Each push is sent with the tenant header set to the next tenant.
+type TenantConfig struct {
+	// Header the name of the tenant header (default: "X-Scope-OrgID")
+	Header string `js:"header"`
+	// IDs the tenants that are rotated
+	IDs []string `js:"ids"`
+	// Weights selects the tenants randomly with the given relative weights instead of round-robin (optional)
+	Weights []float64 `js:"weights"`
+	// Seed makes the weighted selection reproducible per VU and iteration (optional, default: the seed of setSeed)
+	Seed *int64 `js:"seed"`
+}
+
+// tenantRotation selects the tenant of each push.
+type tenantRotation struct {
+	header string
+	ids    []string
+	// cumulative contains the cumulative weights if the tenants are selected randomly
+	cumulative []float64
+	rnd        *random.Source
+	// seeded is set if the random source is reseeded before each selection
+	seeded *seededSource
+	next   atomic.Uint64
+}
+
+func newTenantRotation(cfg *TenantConfig, vu modules.VU) (*tenantRotation, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	if len(cfg.IDs) == 0 {
+		return nil, errors.New("tenants must contain at least one id")
+	}
+
+	r := &tenantRotation{header: cfg.Header, ids: cfg.IDs}
+	if r.header == "" {
+		r.header = defaultTenantHeader
+	}
+	if len(cfg.Weights) > 0 {
+		if len(cfg.Weights) != len(cfg.IDs) {
+			return nil, fmt.Errorf("tenants have %d ids but %d weights", len(cfg.IDs), len(cfg.Weights))
+		}
+		var sum float64
+		for _, w := range cfg.Weights {
+			if w < 0 {
+				return nil, fmt.Errorf("invalid tenant weight %v", w)
+			}
+			sum += w
+			r.cumulative = append(r.cumulative, sum)
+		}
+		if sum == 0 {
+			return nil, errors.New("the sum of the tenant weights must be positive")
+		}
+		if cfg.Seed == nil {
+			r.rnd = random.NewRandomSource()
+		} else {
+			// the seed is derived, so that the tenants don't repeat the random values of generators with the
+			// same seed
+			r.rnd = random.NewSource(0)
+			r.seeded = newSeededSource(r.rnd, random.DeriveSeed(seedValue(*cfg.Seed)), vu)
+		}
+	}
+	return r, nil
+}
+
+// tenant returns the tenant for the next push, it must be called on the event loop.
+func (r *tenantRotation) tenant() string {
+	if r.cumulative == nil {
+		i := r.next.Add(1) - 1
+		return r.ids[i%uint64(len(r.ids))]
+	}
+
+	if r.seeded != nil {
+		r.seeded.reseed()
+	}
+	v := r.rnd.Float64() * r.cumulative[len(r.cumulative)-1]
+	i, _ := slices.BinarySearch(r.cumulative, v)
+	return r.ids[min(i, len(r.ids)-1)]
+}
+
+// pushHeaders returns the headers for a push, which are the next tenant and the headers of the push options.
+func (c *Client) pushHeaders(opts *PushOptions) map[string][]string {
+	var headers map[string][]string
+	if c.tenants != nil {
+		headers = map[string][]string{c.tenants.header: {c.tenants.tenant()}}
+	}
+	if opts != nil && len(opts.Headers) > 0 {
+		if headers == nil {
+			headers = make(map[string][]string, len(opts.Headers))
+		}
+		for name, value := range opts.Headers {
+			headers[name] = []string{value}
+		}
+	}
+	return headers
+}
+
+// contextWithHeaders stores the headers of a push as client metadata. In contrast to other context values, the
+// client metadata of pushes is kept by batches that are partitioned by the metadata keys.
+func contextWithHeaders(ctx context.Context, headers map[string][]string) context.Context {
+	if len(headers) == 0 {
+		return ctx
+	}
+	return client.NewContext(ctx, client.Info{Metadata: client.NewMetadata(headers)})
+}
+
+// headersMiddleware is added to the HTTP and gRPC clients of the exporters and sets the headers of a push on
+// the requests that are sent for it.
+type headersMiddleware struct{}
+
+var (
+	_ extensionmiddleware.HTTPClient = headersMiddleware{}
+	_ extensionmiddleware.GRPCClient = headersMiddleware{}
+)
+
+func (headersMiddleware) Start(context.Context, component.Host) error {
+	return nil
+}
+
+func (headersMiddleware) Shutdown(context.Context) error {
+	return nil
+}
+
+func (headersMiddleware) GetHTTPRoundTripper(context.Context) (extensionmiddleware.WrapHTTPRoundTripperFunc, error) {
+	return func(_ context.Context, next http.RoundTripper) (http.RoundTripper, error) {
+		return headersRoundTripper{next: next}, nil
+	}, nil
+}
+
+func (headersMiddleware) GetGRPCClientOptions(context.Context) ([]grpc.DialOption, error) {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			return invoker(outgoingContextWithHeaders(ctx), method, req, reply, cc, opts...)
+		}),
+	}, nil
+}
+
+type headersRoundTripper struct {
+	next http.RoundTripper
+}
+
+func (rt headersRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	md := client.FromContext(req.Context()).Metadata
+	first := true
+	for name := range md.Keys() {
+		// the request must not be modified by a round tripper
+		if first {
+			req = req.Clone(req.Context())
+			first = false
+		}
+		req.Header.Del(name)
+		for _, value := range md.Get(name) {
+			req.Header.Add(name, value)
+		}
+	}
+	return rt.next.RoundTrip(req)
+}
+
+// outgoingContextWithHeaders adds the headers of a push to the outgoing gRPC metadata. The headers replace the
+// headers of the client, which are already part of the outgoing metadata.
+func outgoingContextWithHeaders(ctx context.Context) context.Context {
+	md := client.FromContext(ctx).Metadata
+	var outgoing metadata.MD
+	for name := range md.Keys() {
+		if outgoing == nil {
+			existing, _ := metadata.FromOutgoingContext(ctx)
+			outgoing = existing.Copy()
+		}
+		outgoing.Set(name, md.Get(name)...)
+	}
+	if outgoing == nil {
+		return ctx
+	}
+	return metadata.NewOutgoingContext(ctx, outgoing)
+}
+
+// exporterHost provides the headers middleware and the token authenticator to the exporters.
+type exporterHost struct {
+	auth *clientAuth
+}
+
+func (h exporterHost) GetExtensions() map[component.ID]component.Component {
+	extensions := map[component.ID]component.Component{headersExtensionID: headersMiddleware{}}
+	if h.auth != nil && h.auth.source != nil {
+		extensions[authExtensionID] = tokenAuthenticator{source: h.auth.source}
+	}
+	return extensions
+}
+
+// headersMiddlewares returns the middleware configuration that enables the headers of pushes.
+func headersMiddlewares() []configmiddleware.Config {
+	return []configmiddleware.Config{{ID: headersExtensionID}}
+}
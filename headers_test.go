@@ -0,0 +1,180 @@
+package clienttracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/v2/js/modulestest"
+	"go.k6.io/k6/v2/lib"
+	"go.opentelemetry.io/collector/config/configopaque"
+
+	"github.com/grafana/xk6-client-tracing/pkg/mockreceiver"
+	"github.com/grafana/xk6-client-tracing/pkg/tracegen"
+)
+
+func TestTenantRotation(t *testing.T) {
+	t.Run("round robin", func(t *testing.T) {
+		r, err := newTenantRotation(&TenantConfig{IDs: []string{"a", "b", "c"}}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, defaultTenantHeader, r.header)
+
+		var tenants []string
+		for range 4 {
+			tenants = append(tenants, r.tenant())
+		}
+		assert.Equal(t, []string{"a", "b", "c", "a"}, tenants)
+	})
+
+	t.Run("weighted", func(t *testing.T) {
+		r, err := newTenantRotation(&TenantConfig{Header: "X-Tenant", IDs: []string{"a", "b", "c"}, Weights: []float64{0, 1, 0}}, nil)
+		require.NoError(t, err)
+		for range 10 {
+			assert.Equal(t, "b", r.tenant())
+		}
+	})
+
+	t.Run("seeded", func(t *testing.T) {
+		vu := &modulestest.VU{StateField: &lib.State{VUID: 1}}
+		seed := int64(42)
+		tenants := func() []string {
+			r, err := newTenantRotation(&TenantConfig{IDs: []string{"a", "b", "c"}, Weights: []float64{1, 1, 1}, Seed: &seed}, vu)
+			require.NoError(t, err)
+			var tenants []string
+			for range 20 {
+				tenants = append(tenants, r.tenant())
+			}
+			return tenants
+		}
+
+		first := tenants()
+		assert.Equal(t, first, tenants())
+		assert.Contains(t, first, "a")
+		assert.Contains(t, first, "b")
+		assert.Contains(t, first, "c")
+
+		vu.StateField.VUID = 2
+		assert.NotEqual(t, first, tenants())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := newTenantRotation(&TenantConfig{}, nil)
+		assert.Error(t, err)
+		_, err = newTenantRotation(&TenantConfig{IDs: []string{"a"}, Weights: []float64{1, 2}}, nil)
+		assert.Error(t, err)
+		_, err = newTenantRotation(&TenantConfig{IDs: []string{"a"}, Weights: []float64{0}}, nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestClient_PushHeadersHTTP(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		tenants []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		tenants = append(tenants, r.Header.Get("X-Scope-OrgID"))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client := newHeadersTestClient(t, &ClientConfig{
+		Exporter: exporterOTLPHTTP,
+		Endpoint: srv.URL,
+		Headers:  map[string]configopaque.String{"X-Scope-OrgID": "default"},
+	})
+
+	traces := testPushTraces()
+	require.NoError(t, client.Push(traces, nil))
+	require.NoError(t, client.Push(traces, &PushOptions{Headers: map[string]string{"X-Scope-OrgID": "override"}}))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"default", "override"}, tenants)
+}
+
+func TestClient_PushHeadersGRPC(t *testing.T) {
+	receiver := startMockReceiver(t)
+	endpoint := receiver.GRPCEndpoint()
+
+	t.Run("tenants", func(t *testing.T) {
+		receiver.Reset()
+		client := newHeadersTestClient(t, &ClientConfig{
+			Endpoint: endpoint,
+			Headers:  map[string]configopaque.String{"X-Scope-OrgID": "default"},
+			Tenants:  &TenantConfig{IDs: []string{"t1", "t2"}},
+		})
+
+		traces := testPushTraces()
+		require.NoError(t, client.Push(traces, nil))
+		require.NoError(t, client.Push(traces, nil))
+		require.NoError(t, client.Push(traces, &PushOptions{Headers: map[string]string{"X-Scope-OrgID": "override"}}))
+		assert.Equal(t, []string{"t1", "t2", "override"}, receiver.HeaderValues(defaultTenantHeader))
+	})
+
+	t.Run("batched", func(t *testing.T) {
+		receiver.Reset()
+		enabled := true
+		client := newHeadersTestClient(t, &ClientConfig{
+			Endpoint: endpoint,
+			Tenants:  &TenantConfig{IDs: []string{"t1", "t2"}},
+			Queue: &QueueConfig{
+				Enabled:       &enabled,
+				WaitForResult: true,
+				Batch:         &BatchConfig{MinSize: 1000, FlushTimeout: "50ms"},
+			},
+		})
+
+		traces := testPushTraces()
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, client.Push(traces, nil))
+			}()
+		}
+		wg.Wait()
+
+		// the pushes are batched per tenant
+		assert.ElementsMatch(t, []string{"t1", "t2"}, receiver.HeaderValues(defaultTenantHeader))
+	})
+}
+
+func newHeadersTestClient(t *testing.T, cfg *ClientConfig) *Client {
+	t.Helper()
+
+	disabled := false
+	cfg.TLS.Insecure = true
+	if cfg.Retry == nil {
+		cfg.Retry = &RetryConfig{Enabled: &disabled}
+	}
+	if cfg.Queue == nil {
+		cfg.Queue = &QueueConfig{Enabled: &disabled}
+	}
+
+	client, err := NewClient(cfg, modulestest.NewRuntime(t).VU, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Shutdown() })
+	return client
+}
+
+func testPushTraces() *Traces {
+	gen := tracegen.NewParameterizedGenerator([]*tracegen.TraceParams{{Count: 1, Spans: tracegen.SpanParams{Count: 1}}}, nil)
+	return newTraces(gen.Traces())
+}
+
+func startMockReceiver(t *testing.T) *mockreceiver.Receiver {
+	t.Helper()
+
+	receiver, err := mockreceiver.Start(nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = receiver.Stop() })
+	return receiver
+}
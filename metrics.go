@@ -0,0 +1,185 @@
+package clienttracing
+
+import (
+	"time"
+
+	"go.k6.io/k6/v2/metrics"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const (
+	metricSpansSent    = "tracing_spans_sent"
+	metricTracesSent   = "tracing_traces_sent"
+	metricBytesSent    = "tracing_bytes_sent"
+	metricPushDuration = "tracing_push_duration"
+	metricPushErrors   = "tracing_push_errors"
+
+	metricTimeToQueryable = "tracing_time_to_queryable"
+	metricTraceMismatches = "tracing_trace_mismatches"
+	metricTracesNotFound  = "tracing_traces_not_found"
+
+	metricSearchDuration = "tracing_search_duration"
+	metricSearchHits     = "tracing_search_hits"
+	metricSearchErrors   = "tracing_search_errors"
+
+	tagExporter = "exporter"
+	tagEndpoint = "endpoint"
+	tagBackend  = "backend"
+)
+
+// tracingMetrics contains the custom k6 metrics emitted by the tracing module.
+type tracingMetrics struct {
+	SpansSent    *metrics.Metric
+	TracesSent   *metrics.Metric
+	BytesSent    *metrics.Metric
+	PushDuration *metrics.Metric
+	PushErrors   *metrics.Metric
+
+	TimeToQueryable *metrics.Metric
+	TraceMismatches *metrics.Metric
+	TracesNotFound  *metrics.Metric
+
+	SearchDuration *metrics.Metric
+	SearchHits     *metrics.Metric
+	SearchErrors   *metrics.Metric
+}
+
+// registerMetrics registers the tracing metrics in the given registry. Metrics that are already registered
+// are reused, so it is safe to call it once per VU.
+func registerMetrics(registry *metrics.Registry) *tracingMetrics {
+	return &tracingMetrics{
+		SpansSent:    registry.MustNewMetric(metricSpansSent, metrics.Counter),
+		TracesSent:   registry.MustNewMetric(metricTracesSent, metrics.Counter),
+		BytesSent:    registry.MustNewMetric(metricBytesSent, metrics.Counter, metrics.Data),
+		PushDuration: registry.MustNewMetric(metricPushDuration, metrics.Trend, metrics.Time),
+		PushErrors:   registry.MustNewMetric(metricPushErrors, metrics.Counter),
+
+		TimeToQueryable: registry.MustNewMetric(metricTimeToQueryable, metrics.Trend, metrics.Time),
+		TraceMismatches: registry.MustNewMetric(metricTraceMismatches, metrics.Counter),
+		TracesNotFound:  registry.MustNewMetric(metricTracesNotFound, metrics.Counter),
+
+		SearchDuration: registry.MustNewMetric(metricSearchDuration, metrics.Trend, metrics.Time),
+		SearchHits:     registry.MustNewMetric(metricSearchHits, metrics.Trend),
+		SearchErrors:   registry.MustNewMetric(metricSearchErrors, metrics.Counter),
+	}
+}
+
+// pushStats describes the outcome of a single push.
+type pushStats struct {
+	spans    int
+	traces   int
+	bytes    int
+	start    time.Time
+	duration time.Duration
+	err      error
+}
+
+// newPushStats counts the spans and traces of a push. The size of the traces is measured as OTLP protobuf, which
+// differs from the bytes sent by exporters with another encoding or compression.
+func newPushStats(traces ptrace.Traces) pushStats {
+	return pushStats{
+		spans:  traces.SpanCount(),
+		traces: countTraceIDs(traces),
+		bytes:  (&ptrace.ProtoMarshaler{}).TracesSize(traces),
+	}
+}
+
+// samples converts the push statistics into k6 samples with the given tags.
+func (m *tracingMetrics) samples(stats pushStats, tags *metrics.TagsAndMeta) metrics.ConnectedSamples {
+	samples := []metrics.Sample{
+		newSample(m.PushDuration, metrics.D(stats.duration), stats.start, tags),
+	}
+	if stats.err != nil {
+		samples = append(samples, newSample(m.PushErrors, 1, stats.start, tags))
+	} else {
+		samples = append(samples,
+			newSample(m.SpansSent, float64(stats.spans), stats.start, tags),
+			newSample(m.TracesSent, float64(stats.traces), stats.start, tags),
+			newSample(m.BytesSent, float64(stats.bytes), stats.start, tags),
+		)
+	}
+
+	return metrics.ConnectedSamples{
+		Samples: samples,
+		Tags:    tags.Tags,
+		Time:    stats.start,
+	}
+}
+
+// verifyStats describes the outcome of verifying a single trace against a query API.
+type verifyStats struct {
+	start           time.Time
+	found           bool
+	timeToQueryable time.Duration
+	mismatches      int
+}
+
+// verifySamples converts the verification statistics into k6 samples with the given tags.
+func (m *tracingMetrics) verifySamples(stats verifyStats, tags *metrics.TagsAndMeta) metrics.ConnectedSamples {
+	var samples []metrics.Sample
+	if stats.found {
+		samples = append(samples,
+			newSample(m.TimeToQueryable, metrics.D(stats.timeToQueryable), stats.start, tags),
+			newSample(m.TraceMismatches, float64(stats.mismatches), stats.start, tags),
+		)
+	} else {
+		samples = append(samples, newSample(m.TracesNotFound, 1, stats.start, tags))
+	}
+
+	return metrics.ConnectedSamples{
+		Samples: samples,
+		Tags:    tags.Tags,
+		Time:    stats.start,
+	}
+}
+
+// searchStats describes the outcome of a single search request.
+type searchStats struct {
+	start    time.Time
+	duration time.Duration
+	hits     int
+	err      error
+}
+
+// searchSamples converts the search statistics into k6 samples with the given tags.
+func (m *tracingMetrics) searchSamples(stats searchStats, tags *metrics.TagsAndMeta) metrics.ConnectedSamples {
+	samples := []metrics.Sample{
+		newSample(m.SearchDuration, metrics.D(stats.duration), stats.start, tags),
+	}
+	if stats.err != nil {
+		samples = append(samples, newSample(m.SearchErrors, 1, stats.start, tags))
+	} else {
+		samples = append(samples, newSample(m.SearchHits, float64(stats.hits), stats.start, tags))
+	}
+
+	return metrics.ConnectedSamples{
+		Samples: samples,
+		Tags:    tags.Tags,
+		Time:    stats.start,
+	}
+}
+
+// newSample returns a sample of a metric with the given tags.
+func newSample(metric *metrics.Metric, value float64, t time.Time, tags *metrics.TagsAndMeta) metrics.Sample {
+	return metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: metric, Tags: tags.Tags},
+		Time:       t,
+		Metadata:   tags.Metadata,
+		Value:      value,
+	}
+}
+
+func countTraceIDs(traces ptrace.Traces) int {
+	traceIDs := map[pcommon.TraceID]struct{}{}
+	for i := 0; i < traces.ResourceSpans().Len(); i++ {
+		rs := traces.ResourceSpans().At(i)
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			ss := rs.ScopeSpans().At(j)
+			for k := 0; k < ss.Spans().Len(); k++ {
+				traceIDs[ss.Spans().At(k).TraceID()] = struct{}{}
+			}
+		}
+	}
+	return len(traceIDs)
+}
@@ -0,0 +1,103 @@
+package clienttracing
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/v2/metrics"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/grafana/xk6-client-tracing/pkg/tracegen"
+)
+
+func TestTracingMetrics_Samples(t *testing.T) {
+	registry := metrics.NewRegistry()
+	m := registerMetrics(registry)
+	tags := &metrics.TagsAndMeta{Tags: registry.RootTagSet().With(tagExporter, string(exporterOTLP))}
+
+	traces := tracegen.NewParameterizedGenerator([]*tracegen.TraceParams{
+		{Count: 2, Spans: tracegen.SpanParams{Count: 3}},
+	}, nil).Traces()
+
+	stats := newPushStats(traces)
+	stats.start = time.Now()
+	stats.duration = 50 * time.Millisecond
+
+	t.Run("success", func(t *testing.T) {
+		samples := m.samples(stats, tags).GetSamples()
+		require.Len(t, samples, 4)
+
+		values := sampleValues(samples)
+		assert.Equal(t, float64(6), values[metricSpansSent])
+		assert.Equal(t, float64(2), values[metricTracesSent])
+		assert.Equal(t, float64(stats.bytes), values[metricBytesSent])
+		assert.Equal(t, float64(50), values[metricPushDuration])
+		assert.NotContains(t, values, metricPushErrors)
+
+		exporter, _ := samples[0].Tags.Get(tagExporter)
+		assert.Equal(t, string(exporterOTLP), exporter)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		stats := stats
+		stats.err = errors.New("push failed")
+
+		values := sampleValues(m.samples(stats, tags).GetSamples())
+		assert.Len(t, values, 2)
+		assert.Equal(t, float64(1), values[metricPushErrors])
+		assert.Contains(t, values, metricPushDuration)
+	})
+}
+
+func TestTracingMetrics_VerifySamples(t *testing.T) {
+	registry := metrics.NewRegistry()
+	m := registerMetrics(registry)
+	tags := &metrics.TagsAndMeta{Tags: registry.RootTagSet().With(tagBackend, string(backendTempo))}
+
+	values := sampleValues(m.verifySamples(verifyStats{
+		start:           time.Now(),
+		found:           true,
+		timeToQueryable: 2 * time.Second,
+		mismatches:      3,
+	}, tags).GetSamples())
+	assert.Equal(t, map[string]float64{metricTimeToQueryable: 2000, metricTraceMismatches: 3}, values)
+
+	values = sampleValues(m.verifySamples(verifyStats{start: time.Now()}, tags).GetSamples())
+	assert.Equal(t, map[string]float64{metricTracesNotFound: 1}, values)
+}
+
+func TestTracingMetrics_SearchSamples(t *testing.T) {
+	registry := metrics.NewRegistry()
+	m := registerMetrics(registry)
+	tags := &metrics.TagsAndMeta{Tags: registry.RootTagSet().With(tagBackend, string(backendTempo))}
+	stats := searchStats{start: time.Now(), duration: 100 * time.Millisecond, hits: 7}
+
+	values := sampleValues(m.searchSamples(stats, tags).GetSamples())
+	assert.Equal(t, map[string]float64{metricSearchDuration: 100, metricSearchHits: 7}, values)
+
+	stats.err = errors.New("search failed")
+	values = sampleValues(m.searchSamples(stats, tags).GetSamples())
+	assert.Equal(t, map[string]float64{metricSearchDuration: 100, metricSearchErrors: 1}, values)
+}
+
+func TestCountTraceIDs(t *testing.T) {
+	traces := ptrace.NewTraces()
+	assert.Equal(t, 0, countTraceIDs(traces))
+
+	traces = tracegen.NewParameterizedGenerator([]*tracegen.TraceParams{
+		{Count: 3, Spans: tracegen.SpanParams{Count: 2}},
+		{Count: 1, Spans: tracegen.SpanParams{Count: 4}},
+	}, nil).Traces()
+	assert.Equal(t, 4, countTraceIDs(traces))
+}
+
+func sampleValues(samples []metrics.Sample) map[string]float64 {
+	values := make(map[string]float64, len(samples))
+	for _, s := range samples {
+		values[s.Metric.Name] = s.Value
+	}
+	return values
+}
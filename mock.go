@@ -0,0 +1,106 @@
+package clienttracing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/sobek"
+	"go.k6.io/k6/v2/js/common"
+
+	"github.com/grafana/xk6-client-tracing/pkg/mockreceiver"
+)
+
+// MockReceiver is the JavaScript representation of an in-process OTLP receiver. It can be used as endpoint of a
+// client for dry runs of a test without a collector or backend.
+type MockReceiver struct {
+	// GRPCEndpoint the address of the OTLP gRPC server
+	GRPCEndpoint string `js:"grpc_endpoint"`
+	// HTTPEndpoint the address of the OTLP HTTP server, traces are received on the path /v1/traces
+	HTTPEndpoint string `js:"http_endpoint"`
+
+	receiver *mockreceiver.Receiver
+}
+
+func (ct *TracingModule) newMockReceiver(g sobek.ConstructorCall, rt *sobek.Runtime) *sobek.Object {
+	var cfg mockreceiver.Config
+	if arg := g.Argument(0); !sobek.IsUndefined(arg) && !sobek.IsNull(arg) {
+		if err := rt.ExportTo(arg, &cfg); err != nil {
+			common.Throw(rt, fmt.Errorf("unable to create mock receiver: constructor expects first argument to be MockReceiverConfig: %w", err))
+		}
+	}
+
+	receiver, err := mockreceiver.Start(&cfg)
+	if err != nil {
+		common.Throw(rt, fmt.Errorf("unable to create mock receiver: %w", err))
+	}
+	// receivers are stopped at the end of the test like clients
+	context.AfterFunc(ct.vu.Context(), func() { _ = receiver.Stop() })
+
+	return rt.ToValue(&MockReceiver{
+		GRPCEndpoint: receiver.GRPCEndpoint(),
+		HTTPEndpoint: receiver.HTTPEndpoint(),
+		receiver:     receiver,
+	}).ToObject(rt)
+}
+
+// RequestCount returns the number of received requests, including the failed ones.
+func (m *MockReceiver) RequestCount() int {
+	return len(m.receiver.Requests())
+}
+
+// SpanCount returns the number of spans of all successful requests.
+func (m *MockReceiver) SpanCount() int {
+	return m.receiver.SpanCount()
+}
+
+// TraceCount returns the number of distinct traces of all successful requests.
+func (m *MockReceiver) TraceCount() int {
+	return m.receiver.TraceCount()
+}
+
+// Traces returns the traces of all successful requests.
+func (m *MockReceiver) Traces() *Traces {
+	return newTraces(m.receiver.Traces())
+}
+
+// HeaderValues returns the values of a header of all requests.
+func (m *MockReceiver) HeaderValues(name string) []string {
+	return m.receiver.HeaderValues(name)
+}
+
+// SetLatency delays all responses by the duration, e.g. "200ms".
+func (m *MockReceiver) SetLatency(latency string) error {
+	var d time.Duration
+	if err := parseDuration(latency, &d); err != nil {
+		return fmt.Errorf("invalid latency: %w", err)
+	}
+	m.receiver.SetLatency(d)
+	return nil
+}
+
+// Fail responds to the next count requests with the HTTP status code, all requests fail if count is omitted.
+func (m *MockReceiver) Fail(statusCode, count int) {
+	m.receiver.Fail(statusCode, count)
+}
+
+// Throttle rejects the next count requests with 429 or RESOURCE_EXHAUSTED, all requests are rejected if count is
+// omitted.
+func (m *MockReceiver) Throttle(retryAfter string, count int) error {
+	var d time.Duration
+	if err := parseDuration(retryAfter, &d); err != nil {
+		return fmt.Errorf("invalid retry after: %w", err)
+	}
+	m.receiver.Throttle(d, count)
+	return nil
+}
+
+// Reset removes all recorded requests, the latency and all failures.
+func (m *MockReceiver) Reset() {
+	m.receiver.Reset()
+}
+
+// Stop stops the receiver, receivers are stopped automatically at the end of the test.
+func (m *MockReceiver) Stop() error {
+	return m.receiver.Stop()
+}
@@ -0,0 +1,122 @@
+package clienttracing
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/v2/js/modulestest"
+	"go.k6.io/k6/v2/lib"
+	"go.k6.io/k6/v2/metrics"
+)
+
+func TestClient_Retry(t *testing.T) {
+	receiver := startMockReceiver(t)
+	enabled := true
+	retry := &RetryConfig{Enabled: &enabled, InitialInterval: "10ms", MaxInterval: "10ms"}
+
+	for name, cfg := range map[string]*ClientConfig{
+		"grpc": {Endpoint: receiver.GRPCEndpoint(), Retry: retry},
+		"http": {Exporter: exporterOTLPHTTP, Endpoint: "http://" + receiver.HTTPEndpoint(), Retry: retry},
+	} {
+		t.Run(name, func(t *testing.T) {
+			receiver.Reset()
+			client := newHeadersTestClient(t, cfg)
+
+			receiver.Fail(http.StatusServiceUnavailable, 2)
+			require.NoError(t, client.Push(testPushTraces(), nil))
+			assert.Len(t, receiver.Requests(), 3)
+			assert.Equal(t, 1, receiver.SpanCount())
+		})
+	}
+}
+
+func TestClient_Throttle(t *testing.T) {
+	receiver := startMockReceiver(t)
+	enabled := true
+	client := newHeadersTestClient(t, &ClientConfig{
+		Endpoint: receiver.GRPCEndpoint(),
+		Retry:    &RetryConfig{Enabled: &enabled, InitialInterval: "10ms", MaxInterval: "10ms"},
+	})
+
+	receiver.Throttle(0, 1)
+	require.NoError(t, client.Push(testPushTraces(), nil))
+	require.Len(t, receiver.Requests(), 2)
+	assert.Equal(t, http.StatusTooManyRequests, receiver.Requests()[0].Status)
+
+	// RESOURCE_EXHAUSTED is only retried if the receiver sends a retry delay
+	receiver.Reset()
+	receiver.Fail(http.StatusTooManyRequests, 1)
+	assert.Error(t, client.Push(testPushTraces(), nil))
+}
+
+func TestClient_Metrics(t *testing.T) {
+	receiver := startMockReceiver(t)
+	runtime := modulestest.NewRuntime(t)
+	m := registerMetrics(runtime.VU.InitEnv().Registry)
+	registry := runtime.VU.InitEnv().Registry
+
+	client, err := NewClient(&ClientConfig{
+		Endpoint: receiver.GRPCEndpoint(),
+		TLS:      TLSClientConfig{Insecure: true},
+		Retry:    &RetryConfig{Enabled: new(bool)},
+		Queue:    &QueueConfig{Enabled: new(bool)},
+	}, runtime.VU, m)
+	require.NoError(t, err)
+
+	samples := make(chan metrics.SampleContainer, 10)
+	runtime.MoveToVUContext(&lib.State{Samples: samples, Tags: lib.NewVUStateTags(registry.RootTagSet())})
+
+	receiver.Fail(http.StatusBadRequest, 1)
+	require.Error(t, client.Push(testPushTraces(), nil))
+	require.NoError(t, client.Push(testPushTraces(), nil))
+	require.NoError(t, client.Shutdown())
+
+	close(samples)
+	var failed, succeeded map[string]float64
+	for container := range samples {
+		values := sampleValues(container.GetSamples())
+		if _, ok := values[metricPushErrors]; ok {
+			failed = values
+		} else {
+			succeeded = values
+		}
+	}
+	assert.Equal(t, float64(1), failed[metricPushErrors])
+	assert.Equal(t, float64(1), succeeded[metricSpansSent])
+	assert.Equal(t, float64(1), succeeded[metricTracesSent])
+}
+
+func TestTracingModule_MockReceiver(t *testing.T) {
+	runtime := modulestest.NewRuntime(t)
+	rt := runtime.VU.Runtime()
+	mi := new(RootModule).NewModuleInstance(runtime.VU)
+	require.NoError(t, rt.Set("tracing", mi.Exports().Named))
+
+	val, err := rt.RunString(`
+		const receiver = new tracing.MockReceiver();
+		const client = new tracing.Client({
+			endpoint: receiver.grpc_endpoint,
+			tls: {insecure: true},
+			retry: {enabled: false},
+			queue: {enabled: false},
+		});
+		const gen = new tracing.ParameterizedGenerator([{count: 2, spans: {count: 3}}]);
+		client.push(gen.traces());
+		receiver.fail(500, 1);
+		let failed = false;
+		try {
+			client.push(gen.traces());
+		} catch (e) {
+			failed = true;
+		}
+		failed && receiver.requestCount() === 2 && receiver.spanCount() === 6 && receiver.traceCount() === 2 &&
+			receiver.traces().spanCount() === 6;
+	`)
+	require.NoError(t, err)
+	assert.True(t, val.ToBoolean())
+
+	_, err = rt.RunString(`receiver.setLatency("soon")`)
+	assert.ErrorContains(t, err, "invalid latency")
+}
@@ -0,0 +1,93 @@
+// Package fileexporter provides an exporter that writes traces to a local file, either as OTLP JSON lines or as
+// length-delimited OTLP protobuf messages.
+package fileexporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/exporter/exporterhelper"
+)
+
+const (
+	FormatJSON  = "json"
+	FormatProto = "proto"
+
+	CompressionGzip = "gzip"
+)
+
+var componentType = component.MustNewType("file")
+
+// Config defines the configuration for the file exporter.
+type Config struct {
+	// Path of the file the traces are written to.
+	Path string
+	// Format of the written traces, either FormatJSON or FormatProto (default: FormatJSON).
+	Format string
+	// Compression of the file, either empty or CompressionGzip.
+	Compression string
+	// Rotation enables the rotation of the file once it reaches a certain size (optional).
+	Rotation *Rotation
+}
+
+// Rotation defines when the file is rotated and how many of the rotated files are kept.
+type Rotation struct {
+	// MaxMegabytes is the maximum size of the file before it is rotated. The size is measured after compression.
+	MaxMegabytes int
+	// MaxBackups is the maximum number of rotated files to keep. If zero, all rotated files are kept.
+	MaxBackups int
+}
+
+// NewFactory creates a factory for the file exporter.
+func NewFactory() exporter.Factory {
+	return exporter.NewFactory(
+		componentType,
+		createDefaultConfig,
+		exporter.WithTraces(createTraces, component.StabilityLevelDevelopment),
+	)
+}
+
+func createDefaultConfig() component.Config {
+	return &Config{
+		Format: FormatJSON,
+	}
+}
+
+func (cfg *Config) validate() error {
+	if cfg.Path == "" {
+		return errors.New("path must be specified")
+	}
+	if cfg.Format != FormatJSON && cfg.Format != FormatProto {
+		return fmt.Errorf("invalid format: %s", cfg.Format)
+	}
+	if cfg.Compression != "" && cfg.Compression != CompressionGzip {
+		return fmt.Errorf("invalid compression: %s", cfg.Compression)
+	}
+	if cfg.Rotation != nil && (cfg.Rotation.MaxMegabytes < 0 || cfg.Rotation.MaxBackups < 0) {
+		return errors.New("rotation limits must not be negative")
+	}
+	return nil
+}
+
+func createTraces(ctx context.Context, set exporter.Settings, cfg component.Config) (exporter.Traces, error) {
+	fCfg := cfg.(*Config)
+	if fCfg.Format == "" {
+		fCfg.Format = FormatJSON
+	}
+	if err := fCfg.validate(); err != nil {
+		return nil, err
+	}
+
+	fe := &fileExporter{config: fCfg}
+
+	return exporterhelper.NewTraces(ctx, set, cfg,
+		fe.pushTraces,
+		exporterhelper.WithStart(fe.start),
+		exporterhelper.WithShutdown(fe.shutdown),
+		exporterhelper.WithCapabilities(consumer.Capabilities{MutatesData: false}),
+	)
+}
@@ -0,0 +1,73 @@
+package fileexporter
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+type fileExporter struct {
+	config *Config
+	writer *fileWriter
+}
+
+// start opens the file. Exporters writing to the same path share a single writer, so that the traces of all
+// VUs end up in the same file.
+func (fe *fileExporter) start(context.Context, component.Host) error {
+	writer, err := acquireWriter(fe.config)
+	if err != nil {
+		return err
+	}
+	fe.writer = writer
+	return nil
+}
+
+func (fe *fileExporter) shutdown(context.Context) error {
+	if fe.writer == nil {
+		return nil
+	}
+	err := releaseWriter(fe.writer)
+	fe.writer = nil
+	return err
+}
+
+func (fe *fileExporter) pushTraces(_ context.Context, td ptrace.Traces) error {
+	if fe.writer == nil {
+		return errors.New("file exporter not started")
+	}
+
+	record, err := marshal(td, fe.config.Format)
+	if err != nil {
+		return consumererror.NewPermanent(fmt.Errorf("failed to serialize traces: %w", err))
+	}
+	if err := fe.writer.write(record); err != nil {
+		return fmt.Errorf("failed to write traces to %s: %w", fe.config.Path, err)
+	}
+	return nil
+}
+
+// marshal serializes the traces into a single record. JSON records are terminated by a new line, protobuf
+// records are prefixed with their length as 4 byte big endian integer.
+func marshal(td ptrace.Traces, format string) ([]byte, error) {
+	switch format {
+	case FormatProto:
+		buf, err := (&ptrace.ProtoMarshaler{}).MarshalTraces(td)
+		if err != nil {
+			return nil, err
+		}
+		record := make([]byte, 4, 4+len(buf))
+		binary.BigEndian.PutUint32(record, uint32(len(buf))) //nolint:gosec // messages are much smaller than 4GiB
+		return append(record, buf...), nil
+	default:
+		buf, err := (&ptrace.JSONMarshaler{}).MarshalTraces(td)
+		if err != nil {
+			return nil, err
+		}
+		return append(buf, '\n'), nil
+	}
+}
@@ -0,0 +1,205 @@
+package fileexporter
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestFileExporter_JSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	exp := newTestExporter(t, &Config{Path: path})
+
+	require.NoError(t, exp.ConsumeTraces(context.Background(), testTraces("first")))
+	require.NoError(t, exp.ConsumeTraces(context.Background(), testTraces("second")))
+	require.NoError(t, exp.Shutdown(context.Background()))
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var names []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		td, err := (&ptrace.JSONUnmarshaler{}).UnmarshalTraces(scanner.Bytes())
+		require.NoError(t, err)
+		names = append(names, td.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Name())
+	}
+	require.NoError(t, scanner.Err())
+	assert.Equal(t, []string{"first", "second"}, names)
+}
+
+func TestFileExporter_ProtoGzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.binpb.gz")
+	exp1 := newTestExporter(t, &Config{Path: path, Format: FormatProto, Compression: CompressionGzip})
+	exp2 := newTestExporter(t, &Config{Path: path, Format: FormatProto, Compression: CompressionGzip})
+
+	require.NoError(t, exp1.ConsumeTraces(context.Background(), testTraces("first")))
+	require.NoError(t, exp2.ConsumeTraces(context.Background(), testTraces("second")))
+	require.NoError(t, exp1.Shutdown(context.Background()))
+	require.NoError(t, exp2.ConsumeTraces(context.Background(), testTraces("third")))
+	require.NoError(t, exp2.Shutdown(context.Background()))
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	data, err := io.ReadAll(gz)
+	require.NoError(t, err)
+
+	var names []string
+	for len(data) > 0 {
+		require.GreaterOrEqual(t, len(data), 4)
+		size := binary.BigEndian.Uint32(data)
+		td, err := (&ptrace.ProtoUnmarshaler{}).UnmarshalTraces(data[4 : 4+size])
+		require.NoError(t, err)
+		names = append(names, td.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Name())
+		data = data[4+size:]
+	}
+	assert.Equal(t, []string{"first", "second", "third"}, names)
+}
+
+func TestFileWriter_ConflictingConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	w, err := acquireWriter(&Config{Path: path, Format: FormatJSON})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, releaseWriter(w)) }()
+
+	for name, cfg := range map[string]*Config{
+		"format":      {Path: path, Format: FormatProto},
+		"compression": {Path: path, Format: FormatJSON, Compression: CompressionGzip},
+		"rotation":    {Path: path, Format: FormatJSON, Rotation: &Rotation{MaxMegabytes: 1}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := acquireWriter(cfg)
+			assert.ErrorContains(t, err, "file "+path+" is already written with")
+		})
+	}
+
+	// the same config reuses the writer
+	same, err := acquireWriter(&Config{Path: filepath.Join(filepath.Dir(path), ".", "traces.jsonl"), Format: FormatJSON})
+	require.NoError(t, err)
+	assert.Same(t, w, same)
+	require.NoError(t, releaseWriter(same))
+}
+
+func TestFileWriter_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "traces.jsonl")
+	w, err := acquireWriter(&Config{Path: path, Rotation: &Rotation{MaxMegabytes: 1, MaxBackups: 1}})
+	require.NoError(t, err)
+
+	record := bytes.Repeat([]byte{'x'}, 600*1024)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, w.write(record))
+	}
+	require.NoError(t, releaseWriter(w))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(record)), info.Size())
+}
+
+func TestFileWriter_RotationSameMillisecond(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "traces.jsonl")
+	w, err := acquireWriter(&Config{Path: path, Rotation: &Rotation{MaxMegabytes: 1, MaxBackups: 2}})
+	require.NoError(t, err)
+
+	// rotating twice in quick succession must not overwrite the first backup
+	record := bytes.Repeat([]byte{'x'}, 600*1024)
+	for i := 0; i < 4; i++ {
+		require.NoError(t, w.write(record))
+	}
+	require.NoError(t, releaseWriter(w))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+	assert.Len(t, files, 3)
+}
+
+func TestFileWriter_RotationGzip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "traces.jsonl.gz")
+	w, err := acquireWriter(&Config{Path: path, Compression: CompressionGzip, Rotation: &Rotation{MaxMegabytes: 1}})
+	require.NoError(t, err)
+
+	// the records compress well, so the file doesn't exceed the maximum size
+	record := bytes.Repeat([]byte{'x'}, 600*1024)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, w.write(record))
+	}
+	require.NoError(t, releaseWriter(w))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{path}, files)
+}
+
+func TestBackupName(t *testing.T) {
+	dir := t.TempDir()
+	ts := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	assert.Equal(t, filepath.Join(dir, "traces-2024-05-01T12-30-00.000.jsonl.gz"), backupName(filepath.Join(dir, "traces.jsonl.gz"), ts))
+	assert.Equal(t, filepath.Join(dir, "traces-2024-05-01T12-30-00.000"), backupName(filepath.Join(dir, "traces"), ts))
+
+	// a counter is appended if a backup with the same timestamp exists
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "traces-2024-05-01T12-30-00.000.jsonl"), nil, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "traces-2024-05-01T12-30-00.000-1.jsonl"), nil, 0o600))
+	name := backupName(filepath.Join(dir, "traces.jsonl"), ts)
+	assert.Equal(t, filepath.Join(dir, "traces-2024-05-01T12-30-00.000-2.jsonl"), name)
+
+	prefix := filepath.Join(dir, "traces")
+	parsed, count, ok := parseBackupName(prefix, ".jsonl", name)
+	require.True(t, ok)
+	assert.Equal(t, ts, parsed)
+	assert.Equal(t, 2, count)
+	_, _, ok = parseBackupName(prefix, ".jsonl", filepath.Join(dir, "traces-2024-05-01T12-30-00.000-x.jsonl"))
+	assert.False(t, ok)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.Error(t, (&Config{Format: FormatJSON}).validate())
+	assert.Error(t, (&Config{Path: "traces", Format: "xml"}).validate())
+	assert.Error(t, (&Config{Path: "traces", Format: FormatJSON, Compression: "zstd"}).validate())
+	assert.NoError(t, (&Config{Path: "traces", Format: FormatProto, Compression: CompressionGzip}).validate())
+}
+
+func newTestExporter(t *testing.T, cfg *Config) exporter.Traces {
+	t.Helper()
+
+	exp, err := NewFactory().CreateTraces(context.Background(), exportertest.NewNopSettings(componentType), cfg)
+	require.NoError(t, err)
+	require.NoError(t, exp.Start(context.Background(), componenttest.NewNopHost()))
+	return exp
+}
+
+func testTraces(name string) ptrace.Traces {
+	traces := ptrace.NewTraces()
+	rs := traces.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("service.name", "test-service")
+	span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetTraceID(pcommon.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+	span.SetSpanID(pcommon.SpanID{1, 2, 3, 4, 5, 6, 7, 8})
+	span.SetName(name)
+	return traces
+}
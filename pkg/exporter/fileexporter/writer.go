@@ -0,0 +1,287 @@
+package fileexporter
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+var writers = struct {
+	sync.Mutex
+	m map[string]*fileWriter
+}{m: make(map[string]*fileWriter)}
+
+// fileWriter appends records to a file and rotates it once it exceeds the configured size.
+type fileWriter struct {
+	mu          sync.Mutex
+	path        string
+	format      string
+	compression string
+	rotation    *Rotation
+	refs        int
+
+	file *os.File
+	// disk counts the bytes written to the file, which are compressed if gzip is enabled
+	disk *countingWriter
+	gz   *gzip.Writer
+	out  io.Writer
+}
+
+// countingWriter counts the bytes written to the underlying writer.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// acquireWriter returns the writer for the path of the config and opens the file if necessary. If there already
+// is a writer for the same path, it is reused if it was created with the same format, compression and rotation.
+func acquireWriter(cfg *Config) (*fileWriter, error) {
+	path, err := filepath.Abs(cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	writers.Lock()
+	defer writers.Unlock()
+
+	if w, found := writers.m[path]; found {
+		if err := w.checkConfig(cfg); err != nil {
+			return nil, err
+		}
+		w.refs++
+		return w, nil
+	}
+
+	w := &fileWriter{
+		path:        path,
+		format:      cfg.Format,
+		compression: cfg.Compression,
+		rotation:    cfg.Rotation,
+		refs:        1,
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	writers.m[path] = w
+	return w, nil
+}
+
+// checkConfig returns an error if the config of another exporter writing to the same file differs, since records
+// of different formats or compressions can't be read from a single file.
+func (w *fileWriter) checkConfig(cfg *Config) error {
+	switch {
+	case cfg.Format != w.format:
+		return fmt.Errorf("file %s is already written with format %s", w.path, w.format)
+	case cfg.Compression != w.compression:
+		return fmt.Errorf("file %s is already written with compression %q", w.path, w.compression)
+	case (cfg.Rotation == nil) != (w.rotation == nil) || cfg.Rotation != nil && *cfg.Rotation != *w.rotation:
+		return fmt.Errorf("file %s is already written with a different rotation", w.path)
+	}
+	return nil
+}
+
+// releaseWriter closes the file once the last exporter using the writer is shut down.
+func releaseWriter(w *fileWriter) error {
+	writers.Lock()
+	defer writers.Unlock()
+
+	w.refs--
+	if w.refs > 0 {
+		return nil
+	}
+	delete(writers.m, w.path)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.close()
+}
+
+func (w *fileWriter) write(record []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.out == nil {
+		return errors.New("file is closed")
+	}
+	if w.exceedsMaxSize(len(record)) {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := w.out.Write(record); err != nil {
+		return err
+	}
+	if w.gz != nil {
+		return w.gz.Flush()
+	}
+	return nil
+}
+
+// exceedsMaxSize returns whether writing a record of n bytes makes the file exceed the maximum size. The compressed
+// size of a record is only known after it was written, so compressed files are rotated once they exceeded the
+// maximum size.
+func (w *fileWriter) exceedsMaxSize(n int) bool {
+	if w.rotation == nil || w.rotation.MaxMegabytes <= 0 || w.disk.n == 0 {
+		return false
+	}
+	if w.gz != nil {
+		n = 0
+	}
+	return w.disk.n+int64(n) > int64(w.rotation.MaxMegabytes)*1024*1024
+}
+
+func (w *fileWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.path), 0o750); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	w.file = file
+	w.disk = &countingWriter{w: file, n: info.Size()}
+	w.out = w.disk
+	if w.compression == CompressionGzip {
+		// appending to an existing file adds another gzip member, which is read transparently by gzip readers
+		w.gz = gzip.NewWriter(w.disk)
+		w.out = w.gz
+	}
+	return nil
+}
+
+func (w *fileWriter) close() error {
+	var errs error
+	if w.gz != nil {
+		errs = w.gz.Close()
+		w.gz = nil
+	}
+	if w.file != nil {
+		errs = errors.Join(errs, w.file.Close())
+		w.file = nil
+	}
+	w.out = nil
+	return errs
+}
+
+// rotate renames the current file to a backup with the current time in its name, removes backups that exceed
+// the configured maximum and opens a new file.
+func (w *fileWriter) rotate() error {
+	if err := w.close(); err != nil {
+		return err
+	}
+	if err := os.Rename(w.path, backupName(w.path, time.Now())); err != nil {
+		return fmt.Errorf("failed to rotate file: %w", err)
+	}
+	if err := w.removeOldBackups(); err != nil {
+		return err
+	}
+	return w.open()
+}
+
+func (w *fileWriter) removeOldBackups() error {
+	if w.rotation.MaxBackups <= 0 {
+		return nil
+	}
+
+	prefix, ext := splitExt(w.path)
+	matches, err := filepath.Glob(prefix + "-*" + ext)
+	if err != nil {
+		return err
+	}
+	type backup struct {
+		path  string
+		t     time.Time
+		count int
+	}
+	var backups []backup
+	for _, m := range matches {
+		if t, count, ok := parseBackupName(prefix, ext, m); ok {
+			backups = append(backups, backup{path: m, t: t, count: count})
+		}
+	}
+	if len(backups) <= w.rotation.MaxBackups {
+		return nil
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].t.Equal(backups[j].t) {
+			return backups[i].t.Before(backups[j].t)
+		}
+		return backups[i].count < backups[j].count
+	})
+	for _, b := range backups[:len(backups)-w.rotation.MaxBackups] {
+		if err := os.Remove(b.path); err != nil {
+			return fmt.Errorf("failed to remove rotated file: %w", err)
+		}
+	}
+	return nil
+}
+
+// backupName inserts the timestamp between the name and the extension of the file,
+// e.g. traces.jsonl becomes traces-2006-01-02T15-04-05.000.jsonl. If the file was already rotated within the
+// same millisecond, a counter is appended to the timestamp, e.g. traces-2006-01-02T15-04-05.000-1.jsonl.
+func backupName(path string, t time.Time) string {
+	prefix, ext := splitExt(path)
+	name := prefix + "-" + t.UTC().Format(backupTimeFormat)
+	backup := name + ext
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(backup); errors.Is(err, fs.ErrNotExist) {
+			return backup
+		}
+		backup = name + "-" + strconv.Itoa(i) + ext
+	}
+}
+
+// parseBackupName returns the timestamp and counter of a backup created by backupName.
+func parseBackupName(prefix, ext, path string) (time.Time, int, bool) {
+	name := strings.TrimSuffix(strings.TrimPrefix(path, prefix+"-"), ext)
+	if len(name) < len(backupTimeFormat) {
+		return time.Time{}, 0, false
+	}
+	t, err := time.Parse(backupTimeFormat, name[:len(backupTimeFormat)])
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	count := 0
+	if suffix := name[len(backupTimeFormat):]; suffix != "" {
+		count, err = strconv.Atoi(strings.TrimPrefix(suffix, "-"))
+		if err != nil || !strings.HasPrefix(suffix, "-") || count < 1 {
+			return time.Time{}, 0, false
+		}
+	}
+	return t, count, true
+}
+
+// splitExt splits the path into the file without extension and the extension. Multiple extensions like
+// .jsonl.gz are treated as one.
+func splitExt(path string) (string, string) {
+	dir, name := filepath.Split(path)
+	if i := strings.Index(name, "."); i > 0 {
+		return dir + name[:i], name[i:]
+	}
+	return path, ""
+}
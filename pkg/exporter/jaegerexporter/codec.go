@@ -0,0 +1,35 @@
+package jaegerexporter
+
+import "fmt"
+
+// gogoMessage is implemented by the gogo/protobuf generated types of the Jaeger API.
+type gogoMessage interface {
+	Marshal() ([]byte, error)
+	Unmarshal(data []byte) error
+}
+
+// Codec is a gRPC codec for the gogo/protobuf generated Jaeger API types. The default gRPC codec can't
+// serialize them since they use custom types for trace and span IDs.
+type Codec struct{}
+
+// Name returns the name of the codec. It is "proto" so that the content subtype matches the one of the
+// default codec used by Jaeger collectors.
+func (Codec) Name() string {
+	return "proto"
+}
+
+func (Codec) Marshal(v any) ([]byte, error) {
+	msg, ok := v.(gogoMessage)
+	if !ok {
+		return nil, fmt.Errorf("failed to marshal: %T is not a gogo/protobuf message", v)
+	}
+	return msg.Marshal()
+}
+
+func (Codec) Unmarshal(data []byte, v any) error {
+	msg, ok := v.(gogoMessage)
+	if !ok {
+		return fmt.Errorf("failed to unmarshal: %T is not a gogo/protobuf message", v)
+	}
+	return msg.Unmarshal(data)
+}
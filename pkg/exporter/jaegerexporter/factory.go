@@ -0,0 +1,175 @@
+// Package jaegerexporter provides exporters that send traces to a Jaeger collector, either using the
+// jaeger.api_v2.CollectorService over gRPC or Thrift over HTTP.
+package jaegerexporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	model "github.com/jaegertracing/jaeger-idl/model/v1"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/configgrpc"
+	"go.opentelemetry.io/collector/config/confighttp"
+	"go.opentelemetry.io/collector/config/configoptional"
+	"go.opentelemetry.io/collector/config/configretry"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/exporter/exporterhelper"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const tracesPath = "/api/traces"
+
+var (
+	componentType           = component.MustNewType("jaeger")
+	thriftHTTPComponentType = component.MustNewType("jaeger_thrift_http")
+)
+
+// Config defines the configuration for the Jaeger gRPC exporter.
+type Config struct {
+	TimeoutConfig exporterhelper.TimeoutConfig
+	ClientConfig  configgrpc.ClientConfig
+	QueueConfig   configoptional.Optional[exporterhelper.QueueBatchConfig]
+	RetryConfig   configretry.BackOffConfig
+}
+
+// ThriftHTTPConfig defines the configuration for the Jaeger Thrift HTTP exporter.
+type ThriftHTTPConfig struct {
+	ClientConfig confighttp.ClientConfig
+	QueueConfig  configoptional.Optional[exporterhelper.QueueBatchConfig]
+	RetryConfig  configretry.BackOffConfig
+}
+
+// NewFactory creates a factory for the Jaeger gRPC exporter.
+func NewFactory() exporter.Factory {
+	return exporter.NewFactory(
+		componentType,
+		createDefaultConfig,
+		exporter.WithTraces(createTraces, component.StabilityLevelDevelopment),
+	)
+}
+
+// NewThriftHTTPFactory creates a factory for the Jaeger Thrift HTTP exporter.
+func NewThriftHTTPFactory() exporter.Factory {
+	return exporter.NewFactory(
+		thriftHTTPComponentType,
+		createDefaultThriftHTTPConfig,
+		exporter.WithTraces(createThriftHTTPTraces, component.StabilityLevelDevelopment),
+	)
+}
+
+func createDefaultConfig() component.Config {
+	return &Config{
+		TimeoutConfig: exporterhelper.NewDefaultTimeoutConfig(),
+		ClientConfig:  configgrpc.NewDefaultClientConfig(),
+		QueueConfig:   configoptional.Some(exporterhelper.NewDefaultQueueConfig()),
+		RetryConfig:   configretry.NewDefaultBackOffConfig(),
+	}
+}
+
+func createDefaultThriftHTTPConfig() component.Config {
+	clientConfig := confighttp.NewDefaultClientConfig()
+	clientConfig.Timeout = 5 * time.Second
+
+	return &ThriftHTTPConfig{
+		ClientConfig: clientConfig,
+		QueueConfig:  configoptional.Some(exporterhelper.NewDefaultQueueConfig()),
+		RetryConfig:  configretry.NewDefaultBackOffConfig(),
+	}
+}
+
+func createTraces(ctx context.Context, set exporter.Settings, cfg component.Config) (exporter.Traces, error) {
+	jCfg := cfg.(*Config)
+	if jCfg.ClientConfig.Endpoint == "" {
+		return nil, errors.New("endpoint must be specified")
+	}
+
+	je := &grpcExporter{
+		config:   jCfg,
+		settings: set.TelemetrySettings,
+	}
+
+	return exporterhelper.NewTraces(ctx, set, cfg,
+		je.pushTraces,
+		exporterhelper.WithStart(je.start),
+		exporterhelper.WithShutdown(je.shutdown),
+		exporterhelper.WithCapabilities(consumer.Capabilities{MutatesData: false}),
+		exporterhelper.WithTimeout(jCfg.TimeoutConfig),
+		exporterhelper.WithRetry(jCfg.RetryConfig),
+		exporterhelper.WithQueue(jCfg.QueueConfig),
+	)
+}
+
+func createThriftHTTPTraces(ctx context.Context, set exporter.Settings, cfg component.Config) (exporter.Traces, error) {
+	jCfg := cfg.(*ThriftHTTPConfig)
+
+	tracesURL, err := composeTracesURL(jCfg.ClientConfig.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	je := &thriftHTTPExporter{
+		config:    jCfg,
+		tracesURL: tracesURL,
+		settings:  set.TelemetrySettings,
+	}
+
+	return exporterhelper.NewTraces(ctx, set, cfg,
+		je.pushTraces,
+		exporterhelper.WithStart(je.start),
+		exporterhelper.WithCapabilities(consumer.Capabilities{MutatesData: false}),
+		// explicitly disable since we rely on http.Client timeout logic.
+		exporterhelper.WithTimeout(exporterhelper.TimeoutConfig{Timeout: 0}),
+		exporterhelper.WithRetry(jCfg.RetryConfig),
+		exporterhelper.WithQueue(jCfg.QueueConfig),
+	)
+}
+
+// composeTracesURL appends the path of the Jaeger collector traces API to the endpoint unless it already
+// contains a path.
+func composeTracesURL(endpoint string) (string, error) {
+	if endpoint == "" {
+		return "", errors.New("endpoint must be specified")
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("endpoint must be a valid URL: %w", err)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = tracesPath
+	}
+	return u.String(), nil
+}
+
+// pushBatches sends the batch of each resource and stops at the first error. Unless the error is permanent, it
+// contains the resources that weren't sent yet, so that retries don't send batches twice.
+func pushBatches(td ptrace.Traces, push func(*model.Batch) error) error {
+	for i := 0; i < td.ResourceSpans().Len(); i++ {
+		batch := fromResourceSpans(td.ResourceSpans().At(i))
+		if len(batch.Spans) == 0 {
+			continue
+		}
+		err := push(batch)
+		if err == nil {
+			continue
+		}
+		if consumererror.IsPermanent(err) {
+			return err
+		}
+		unsent := ptrace.NewTraces()
+		for j := i; j < td.ResourceSpans().Len(); j++ {
+			td.ResourceSpans().At(j).CopyTo(unsent.ResourceSpans().AppendEmpty())
+		}
+		return consumererror.NewTraces(err, unsent)
+	}
+	return nil
+}
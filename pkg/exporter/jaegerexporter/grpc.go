@@ -0,0 +1,81 @@
+package jaegerexporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	model "github.com/jaegertracing/jaeger-idl/model/v1"
+	"github.com/jaegertracing/jaeger-idl/proto-gen/api_v2"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/configgrpc"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type grpcExporter struct {
+	config     *Config
+	settings   component.TelemetrySettings
+	clientConn *grpc.ClientConn
+	client     api_v2.CollectorServiceClient
+	metadata   metadata.MD
+}
+
+// start creates the gRPC connection. The connection is created here since extensions are only available
+// once the exporter is started.
+func (je *grpcExporter) start(ctx context.Context, host component.Host) error {
+	clientConn, err := je.config.ClientConfig.ToClientConn(ctx, host.GetExtensions(), je.settings,
+		configgrpc.WithGrpcDialOption(grpc.WithDefaultCallOptions(grpc.ForceCodec(Codec{}))))
+	if err != nil {
+		return err
+	}
+	je.clientConn = clientConn
+	je.client = api_v2.NewCollectorServiceClient(clientConn)
+
+	headers := map[string]string{}
+	for k, v := range je.config.ClientConfig.Headers.Iter {
+		headers[k] = string(v)
+	}
+	je.metadata = metadata.New(headers)
+	return nil
+}
+
+func (je *grpcExporter) shutdown(context.Context) error {
+	if je.clientConn != nil {
+		return je.clientConn.Close()
+	}
+	return nil
+}
+
+func (je *grpcExporter) pushTraces(ctx context.Context, td ptrace.Traces) error {
+	if je.client == nil {
+		return errors.New("jaeger exporter not started")
+	}
+	if len(je.metadata) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, je.metadata.Copy())
+	}
+
+	return pushBatches(td, func(batch *model.Batch) error {
+		_, err := je.client.PostSpans(ctx, &api_v2.PostSpansRequest{Batch: *batch},
+			grpc.WaitForReady(je.config.ClientConfig.WaitForReady))
+		return processError(err)
+	})
+}
+
+// processError marks errors that can't be fixed by retrying the request as permanent.
+func processError(err error) error {
+	st := status.Convert(err)
+	switch st.Code() {
+	case codes.OK:
+		return nil
+	case codes.Canceled, codes.DeadlineExceeded, codes.Aborted, codes.OutOfRange, codes.Unavailable,
+		codes.DataLoss, codes.ResourceExhausted:
+		return fmt.Errorf("failed to push jaeger spans: %w", err)
+	default:
+		return consumererror.NewPermanent(fmt.Errorf("failed to push jaeger spans: %w", err))
+	}
+}
@@ -0,0 +1,264 @@
+package jaegerexporter
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	model "github.com/jaegertracing/jaeger-idl/model/v1"
+	"github.com/jaegertracing/jaeger-idl/proto-gen/api_v2"
+	"github.com/jaegertracing/jaeger-idl/thrift-gen/jaeger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/config/configoptional"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/exporter/exporterhelper"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestFromTraces(t *testing.T) {
+	batches := FromTraces(testTraces())
+	require.Len(t, batches, 1)
+
+	batch := batches[0]
+	assert.Equal(t, "test-service", batch.Process.ServiceName)
+	require.Len(t, batch.Process.Tags, 1)
+	assert.Equal(t, model.Bool("k6", true), batch.Process.Tags[0])
+
+	require.Len(t, batch.Spans, 2)
+	client, server := batch.Spans[0], batch.Spans[1]
+
+	assert.Equal(t, "client", client.OperationName)
+	assert.Equal(t, 100*time.Millisecond, client.Duration)
+	kind, _ := client.GetSpanKind()
+	assert.Equal(t, model.SpanKindClient, kind)
+	assertTag(t, client.Tags, model.String("http.request.method", "GET"))
+	assertTag(t, client.Tags, model.Int64("http.response.status_code", 500))
+	assertTag(t, client.Tags, model.Bool(tagError, true))
+	assertTag(t, client.Tags, model.String(tagStatusDescription, "Internal Server Error"))
+	require.Len(t, client.Logs, 1)
+	assert.Equal(t, []model.KeyValue{model.String(tagEvent, "exception"), model.String("exception.message", "boom")}, client.Logs[0].Fields)
+
+	require.Len(t, server.References, 2)
+	assert.Equal(t, model.NewChildOfRef(client.TraceID, client.SpanID), server.References[0])
+	assert.Equal(t, model.SpanRefType_FOLLOWS_FROM, server.References[1].RefType)
+	assert.Equal(t, client.TraceID, server.TraceID)
+}
+
+func TestJaegerExporter_PushTracesGRPC(t *testing.T) {
+	collector := &testCollector{}
+	endpoint := startTestCollector(t, collector)
+
+	factory := NewFactory()
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.ClientConfig.Endpoint = endpoint
+	cfg.ClientConfig.TLS.Insecure = true
+	cfg.ClientConfig.Headers.Set("X-Test", "test-value")
+	cfg.QueueConfig = configoptional.None[exporterhelper.QueueBatchConfig]()
+	cfg.RetryConfig.Enabled = false
+
+	exp := startTestExporter(t, factory, cfg)
+	require.NoError(t, exp.ConsumeTraces(context.Background(), testTraces()))
+
+	require.Len(t, collector.batches, 1)
+	assert.Equal(t, []string{"test-value"}, collector.metadata.Get("X-Test"))
+	assert.Equal(t, "test-service", collector.batches[0].Process.ServiceName)
+	require.Len(t, collector.batches[0].Spans, 2)
+	assert.Equal(t, "server", collector.batches[0].Spans[1].OperationName)
+	assert.Equal(t, collector.batches[0].Spans[0].SpanID, collector.batches[0].Spans[1].ParentSpanID())
+
+	collector.err = status.Error(codes.InvalidArgument, "invalid batch")
+	assert.Error(t, exp.ConsumeTraces(context.Background(), testTraces()))
+}
+
+func TestJaegerExporter_PushTracesGRPCUnsent(t *testing.T) {
+	collector := &testCollector{failService: "service-b", err: status.Error(codes.Unavailable, "unavailable")}
+	endpoint := startTestCollector(t, collector)
+
+	factory := NewFactory()
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.ClientConfig.Endpoint = endpoint
+	cfg.ClientConfig.TLS.Insecure = true
+	cfg.QueueConfig = configoptional.None[exporterhelper.QueueBatchConfig]()
+	cfg.RetryConfig.Enabled = false
+
+	traces := ptrace.NewTraces()
+	for _, service := range []string{"service-a", "service-b", "service-c"} {
+		rs := traces.ResourceSpans().AppendEmpty()
+		rs.Resource().Attributes().PutStr(attrServiceName, service)
+		rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("span")
+	}
+
+	exp := startTestExporter(t, factory, cfg)
+	err := exp.ConsumeTraces(context.Background(), traces)
+
+	// batches after the failed one aren't sent, only the failed and the following batches are retried
+	require.Len(t, collector.batches, 1)
+	assert.Equal(t, "service-a", collector.batches[0].Process.ServiceName)
+	var tracesErr consumererror.Traces
+	require.ErrorAs(t, err, &tracesErr)
+	unsent := tracesErr.Data()
+	require.Equal(t, 2, unsent.ResourceSpans().Len())
+	service, _ := unsent.ResourceSpans().At(0).Resource().Attributes().Get(attrServiceName)
+	assert.Equal(t, "service-b", service.Str())
+}
+
+func TestJaegerExporter_PushTracesThriftHTTP(t *testing.T) {
+	var received jaeger.Batch
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, tracesPath, r.URL.Path)
+		assert.Equal(t, thriftContentType, r.Header.Get("Content-Type"))
+		assert.Equal(t, "test-value", r.Header.Get("X-Test"))
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, thrift.NewTDeserializer().Read(r.Context(), &received, body))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	factory := NewThriftHTTPFactory()
+	cfg := factory.CreateDefaultConfig().(*ThriftHTTPConfig)
+	cfg.ClientConfig.Endpoint = server.URL
+	cfg.ClientConfig.Headers.Set("X-Test", "test-value")
+	cfg.QueueConfig = configoptional.None[exporterhelper.QueueBatchConfig]()
+	cfg.RetryConfig.Enabled = false
+
+	exp := startTestExporter(t, factory, cfg)
+	require.NoError(t, exp.ConsumeTraces(context.Background(), testTraces()))
+
+	assert.Equal(t, "test-service", received.Process.ServiceName)
+	require.Len(t, received.Spans, 2)
+	client, srv := received.Spans[0], received.Spans[1]
+	assert.Equal(t, "client", client.OperationName)
+	assert.Equal(t, int64(100_000), client.Duration)
+	assert.Equal(t, client.SpanId, srv.ParentSpanId)
+	assert.Equal(t, client.TraceIdHigh, srv.TraceIdHigh)
+	assert.Equal(t, client.TraceIdLow, srv.TraceIdLow)
+	require.Len(t, srv.References, 2)
+	assert.Equal(t, jaeger.SpanRefType_CHILD_OF, srv.References[0].RefType)
+	assert.Equal(t, jaeger.SpanRefType_FOLLOWS_FROM, srv.References[1].RefType)
+	require.Len(t, client.Logs, 1)
+	assert.Equal(t, "exception", *client.Logs[0].Fields[0].VStr)
+}
+
+func TestComposeTracesURL(t *testing.T) {
+	for endpoint, expected := range map[string]string{
+		"localhost:14268":                   "http://localhost:14268/api/traces",
+		"https://jaeger.example.com/":       "https://jaeger.example.com/api/traces",
+		"http://localhost:14268/custom/api": "http://localhost:14268/custom/api",
+	} {
+		u, err := composeTracesURL(endpoint)
+		require.NoError(t, err)
+		assert.Equal(t, expected, u)
+	}
+
+	_, err := composeTracesURL("")
+	assert.Error(t, err)
+}
+
+type testCollector struct {
+	mu       sync.Mutex
+	batches  []model.Batch
+	metadata metadata.MD
+	err      error
+	// failService only fails the batches of this service if set
+	failService string
+}
+
+func (c *testCollector) PostSpans(ctx context.Context, req *api_v2.PostSpansRequest) (*api_v2.PostSpansResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil && (c.failService == "" || c.failService == req.Batch.Process.ServiceName) {
+		return nil, c.err
+	}
+	c.metadata, _ = metadata.FromIncomingContext(ctx)
+	c.batches = append(c.batches, req.Batch)
+	return &api_v2.PostSpansResponse{}, nil
+}
+
+func startTestCollector(t *testing.T, collector api_v2.CollectorServiceServer) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer(grpc.ForceServerCodec(Codec{}))
+	api_v2.RegisterCollectorServiceServer(server, collector)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	return lis.Addr().String()
+}
+
+func startTestExporter(t *testing.T, factory exporter.Factory, cfg any) exporter.Traces {
+	t.Helper()
+
+	exp, err := factory.CreateTraces(context.Background(), exportertest.NewNopSettings(factory.Type()), cfg)
+	require.NoError(t, err)
+	require.NoError(t, exp.Start(context.Background(), componenttest.NewNopHost()))
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(context.Background())) })
+	return exp
+}
+
+func assertTag(t *testing.T, tags []model.KeyValue, expected model.KeyValue) {
+	t.Helper()
+
+	tag, found := model.KeyValues(tags).FindByKey(expected.Key)
+	require.True(t, found, "tag %s not found", expected.Key)
+	assert.Equal(t, expected, tag)
+}
+
+func testTraces() ptrace.Traces {
+	traces := ptrace.NewTraces()
+	rs := traces.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr(attrServiceName, "test-service")
+	rs.Resource().Attributes().PutBool("k6", true)
+	ss := rs.ScopeSpans().AppendEmpty()
+
+	start := time.Now()
+	client := ss.Spans().AppendEmpty()
+	client.SetTraceID(pcommon.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+	client.SetSpanID(pcommon.SpanID{1, 2, 3, 4, 5, 6, 7, 8})
+	client.SetName("client")
+	client.SetKind(ptrace.SpanKindClient)
+	client.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+	client.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(100 * time.Millisecond)))
+	client.Attributes().PutStr("http.request.method", "GET")
+	client.Attributes().PutInt("http.response.status_code", 500)
+	client.Status().SetCode(ptrace.StatusCodeError)
+	client.Status().SetMessage("Internal Server Error")
+	event := client.Events().AppendEmpty()
+	event.SetName("exception")
+	event.SetTimestamp(client.StartTimestamp())
+	event.Attributes().PutStr("exception.message", "boom")
+
+	server := ss.Spans().AppendEmpty()
+	server.SetTraceID(client.TraceID())
+	server.SetSpanID(pcommon.SpanID{8, 7, 6, 5, 4, 3, 2, 1})
+	server.SetParentSpanID(client.SpanID())
+	server.SetName("server")
+	server.SetKind(ptrace.SpanKindServer)
+	server.SetStartTimestamp(pcommon.NewTimestampFromTime(start.Add(10 * time.Millisecond)))
+	server.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(90 * time.Millisecond)))
+	link := server.Links().AppendEmpty()
+	link.SetTraceID(pcommon.TraceID{16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1})
+	link.SetSpanID(pcommon.SpanID{1, 1, 1, 1, 1, 1, 1, 1})
+
+	return traces
+}
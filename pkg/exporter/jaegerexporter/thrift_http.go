@@ -0,0 +1,70 @@
+package jaegerexporter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	model "github.com/jaegertracing/jaeger-idl/model/v1"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const thriftContentType = "application/x-thrift"
+
+type thriftHTTPExporter struct {
+	config    *ThriftHTTPConfig
+	tracesURL string
+	settings  component.TelemetrySettings
+	client    *http.Client
+}
+
+// start creates the HTTP client. The client construction is deferred till this point as this is the only place
+// we get hold of extensions which are required to construct auth round tripper.
+func (je *thriftHTTPExporter) start(ctx context.Context, host component.Host) error {
+	client, err := je.config.ClientConfig.ToClient(ctx, host.GetExtensions(), je.settings)
+	if err != nil {
+		return err
+	}
+	je.client = client
+	return nil
+}
+
+func (je *thriftHTTPExporter) pushTraces(ctx context.Context, td ptrace.Traces) error {
+	serializer := thrift.NewTSerializer()
+	return pushBatches(td, func(batch *model.Batch) error {
+		body, err := serializer.Write(ctx, ToThrift(batch))
+		if err != nil {
+			return consumererror.NewPermanent(fmt.Errorf("failed to serialize jaeger batch: %w", err))
+		}
+		return je.post(ctx, body)
+	})
+}
+
+func (je *thriftHTTPExporter) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, je.tracesURL, bytes.NewReader(body))
+	if err != nil {
+		return consumererror.NewPermanent(err)
+	}
+	req.Header.Set("Content-Type", thriftContentType)
+
+	resp, err := je.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push jaeger spans: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		err = fmt.Errorf("failed to push jaeger spans: %s responded with HTTP status %s", je.tracesURL, resp.Status)
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return consumererror.NewPermanent(err)
+		}
+		return err
+	}
+	return nil
+}
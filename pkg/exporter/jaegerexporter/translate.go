@@ -0,0 +1,244 @@
+package jaegerexporter
+
+import (
+	"encoding/binary"
+
+	model "github.com/jaegertracing/jaeger-idl/model/v1"
+	"github.com/jaegertracing/jaeger-idl/thrift-gen/jaeger"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const (
+	attrServiceName = "service.name"
+
+	tagError             = "error"
+	tagStatusCode        = "otel.status_code"
+	tagStatusDescription = "otel.status_description"
+	tagScopeName         = "otel.scope.name"
+	tagScopeVersion      = "otel.scope.version"
+	tagEvent             = "event"
+)
+
+// FromTraces converts traces into Jaeger batches, one for each resource. The resource becomes the process of
+// the batch, the parent of a span is represented by a CHILD_OF reference and links by FOLLOWS_FROM references.
+// Events become logs.
+func FromTraces(td ptrace.Traces) []*model.Batch {
+	batches := make([]*model.Batch, 0, td.ResourceSpans().Len())
+
+	for i := 0; i < td.ResourceSpans().Len(); i++ {
+		if batch := fromResourceSpans(td.ResourceSpans().At(i)); len(batch.Spans) > 0 {
+			batches = append(batches, batch)
+		}
+	}
+
+	return batches
+}
+
+// fromResourceSpans converts the spans of a single resource into a Jaeger batch.
+func fromResourceSpans(rs ptrace.ResourceSpans) *model.Batch {
+	batch := &model.Batch{Process: process(rs.Resource())}
+	for j := 0; j < rs.ScopeSpans().Len(); j++ {
+		ss := rs.ScopeSpans().At(j)
+		for k := 0; k < ss.Spans().Len(); k++ {
+			batch.Spans = append(batch.Spans, fromSpan(ss.Spans().At(k), ss.Scope()))
+		}
+	}
+	return batch
+}
+
+func process(resource pcommon.Resource) *model.Process {
+	p := &model.Process{ServiceName: "unknown_service"}
+	resource.Attributes().Range(func(k string, v pcommon.Value) bool {
+		if k == attrServiceName {
+			p.ServiceName = v.AsString()
+		} else {
+			p.Tags = append(p.Tags, keyValue(k, v))
+		}
+		return true
+	})
+	return p
+}
+
+func fromSpan(span ptrace.Span, scope pcommon.InstrumentationScope) *model.Span {
+	js := &model.Span{
+		TraceID:       traceID(span.TraceID()),
+		SpanID:        spanID(span.SpanID()),
+		OperationName: span.Name(),
+		Flags:         model.SampledFlag,
+		StartTime:     span.StartTimestamp().AsTime(),
+		Duration:      span.EndTimestamp().AsTime().Sub(span.StartTimestamp().AsTime()),
+		Tags:          make([]model.KeyValue, 0, span.Attributes().Len()+5),
+	}
+
+	if !span.ParentSpanID().IsEmpty() {
+		js.References = append(js.References, model.NewChildOfRef(js.TraceID, spanID(span.ParentSpanID())))
+	}
+	for i := 0; i < span.Links().Len(); i++ {
+		link := span.Links().At(i)
+		js.References = append(js.References, model.NewFollowsFromRef(traceID(link.TraceID()), spanID(link.SpanID())))
+	}
+
+	span.Attributes().Range(func(k string, v pcommon.Value) bool {
+		js.Tags = append(js.Tags, keyValue(k, v))
+		return true
+	})
+	if k := kind(span.Kind()); k != "" {
+		js.Tags = append(js.Tags, model.SpanKindTag(k))
+	}
+	if scope.Name() != "" {
+		js.Tags = append(js.Tags, model.String(tagScopeName, scope.Name()))
+	}
+	if scope.Version() != "" {
+		js.Tags = append(js.Tags, model.String(tagScopeVersion, scope.Version()))
+	}
+
+	switch span.Status().Code() {
+	case ptrace.StatusCodeError:
+		js.Tags = append(js.Tags, model.String(tagStatusCode, "ERROR"), model.Bool(tagError, true))
+		if msg := span.Status().Message(); msg != "" {
+			js.Tags = append(js.Tags, model.String(tagStatusDescription, msg))
+		}
+	case ptrace.StatusCodeOk:
+		js.Tags = append(js.Tags, model.String(tagStatusCode, "OK"))
+	}
+
+	for i := 0; i < span.Events().Len(); i++ {
+		js.Logs = append(js.Logs, spanLog(span.Events().At(i)))
+	}
+
+	return js
+}
+
+// spanLog converts an event into a log. The event name is stored in the "event" field.
+func spanLog(event ptrace.SpanEvent) model.Log {
+	l := model.Log{
+		Timestamp: event.Timestamp().AsTime(),
+		Fields:    make([]model.KeyValue, 0, event.Attributes().Len()+1),
+	}
+	if event.Name() != "" {
+		l.Fields = append(l.Fields, model.String(tagEvent, event.Name()))
+	}
+	event.Attributes().Range(func(k string, v pcommon.Value) bool {
+		l.Fields = append(l.Fields, keyValue(k, v))
+		return true
+	})
+	return l
+}
+
+func keyValue(k string, v pcommon.Value) model.KeyValue {
+	switch v.Type() {
+	case pcommon.ValueTypeBool:
+		return model.Bool(k, v.Bool())
+	case pcommon.ValueTypeInt:
+		return model.Int64(k, v.Int())
+	case pcommon.ValueTypeDouble:
+		return model.Float64(k, v.Double())
+	case pcommon.ValueTypeBytes:
+		return model.Binary(k, v.Bytes().AsRaw())
+	default:
+		return model.String(k, v.AsString())
+	}
+}
+
+func kind(k ptrace.SpanKind) model.SpanKind {
+	switch k {
+	case ptrace.SpanKindClient:
+		return model.SpanKindClient
+	case ptrace.SpanKindServer:
+		return model.SpanKindServer
+	case ptrace.SpanKindProducer:
+		return model.SpanKindProducer
+	case ptrace.SpanKindConsumer:
+		return model.SpanKindConsumer
+	case ptrace.SpanKindInternal:
+		return model.SpanKindInternal
+	default:
+		return ""
+	}
+}
+
+func traceID(id pcommon.TraceID) model.TraceID {
+	return model.NewTraceID(binary.BigEndian.Uint64(id[:8]), binary.BigEndian.Uint64(id[8:]))
+}
+
+func spanID(id pcommon.SpanID) model.SpanID {
+	return model.NewSpanID(binary.BigEndian.Uint64(id[:]))
+}
+
+// ToThrift converts a batch into its Thrift representation.
+func ToThrift(batch *model.Batch) *jaeger.Batch {
+	tb := &jaeger.Batch{
+		Process: &jaeger.Process{
+			ServiceName: batch.Process.ServiceName,
+			Tags:        thriftTags(batch.Process.Tags),
+		},
+		Spans: make([]*jaeger.Span, 0, len(batch.Spans)),
+	}
+
+	for _, span := range batch.Spans {
+		ts := &jaeger.Span{
+			TraceIdLow:    signed(span.TraceID.Low),
+			TraceIdHigh:   signed(span.TraceID.High),
+			SpanId:        signed(uint64(span.SpanID)),
+			OperationName: span.OperationName,
+			Flags:         int32(span.Flags & 0xff),
+			StartTime:     span.StartTime.UnixMicro(),
+			Duration:      span.Duration.Microseconds(),
+			Tags:          thriftTags(span.Tags),
+		}
+		for _, ref := range span.References {
+			refType := jaeger.SpanRefType_FOLLOWS_FROM
+			if ref.RefType == model.SpanRefType_CHILD_OF {
+				refType = jaeger.SpanRefType_CHILD_OF
+				ts.ParentSpanId = signed(uint64(ref.SpanID))
+			}
+			ts.References = append(ts.References, &jaeger.SpanRef{
+				RefType:     refType,
+				TraceIdLow:  signed(ref.TraceID.Low),
+				TraceIdHigh: signed(ref.TraceID.High),
+				SpanId:      signed(uint64(ref.SpanID)),
+			})
+		}
+		for _, l := range span.Logs {
+			ts.Logs = append(ts.Logs, &jaeger.Log{
+				Timestamp: l.Timestamp.UnixMicro(),
+				Fields:    thriftTags(l.Fields),
+			})
+		}
+		tb.Spans = append(tb.Spans, ts)
+	}
+
+	return tb
+}
+
+func thriftTags(kvs []model.KeyValue) []*jaeger.Tag {
+	tags := make([]*jaeger.Tag, 0, len(kvs))
+	for _, kv := range kvs {
+		tag := &jaeger.Tag{Key: kv.Key}
+		switch kv.VType {
+		case model.ValueType_BOOL:
+			tag.VType = jaeger.TagType_BOOL
+			tag.VBool = &kv.VBool
+		case model.ValueType_INT64:
+			tag.VType = jaeger.TagType_LONG
+			tag.VLong = &kv.VInt64
+		case model.ValueType_FLOAT64:
+			tag.VType = jaeger.TagType_DOUBLE
+			tag.VDouble = &kv.VFloat64
+		case model.ValueType_BINARY:
+			tag.VType = jaeger.TagType_BINARY
+			tag.VBinary = kv.VBinary
+		default:
+			tag.VType = jaeger.TagType_STRING
+			tag.VStr = &kv.VStr
+		}
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// signed reinterprets an unsigned ID as the signed integer used by the Thrift API.
+func signed(id uint64) int64 {
+	return int64(id) //nolint:gosec // the bits are kept as is, overflows are expected
+}
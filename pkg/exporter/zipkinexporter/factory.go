@@ -0,0 +1,108 @@
+// Package zipkinexporter provides an exporter that sends traces to the Zipkin v2 API.
+package zipkinexporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/confighttp"
+	"go.opentelemetry.io/collector/config/configoptional"
+	"go.opentelemetry.io/collector/config/configretry"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/exporter/exporterhelper"
+)
+
+const (
+	spansPath = "/api/v2/spans"
+
+	EncodingJSON  = "json"
+	EncodingProto = "proto"
+)
+
+var componentType = component.MustNewType("zipkin")
+
+// Config defines the configuration for the Zipkin exporter.
+type Config struct {
+	ClientConfig confighttp.ClientConfig
+	QueueConfig  configoptional.Optional[exporterhelper.QueueBatchConfig]
+	RetryConfig  configretry.BackOffConfig
+	// Encoding of the spans sent to Zipkin, either EncodingJSON or EncodingProto (default: EncodingJSON).
+	Encoding string
+}
+
+// NewFactory creates a factory for the Zipkin exporter.
+func NewFactory() exporter.Factory {
+	return exporter.NewFactory(
+		componentType,
+		createDefaultConfig,
+		exporter.WithTraces(createTraces, component.StabilityLevelDevelopment),
+	)
+}
+
+func createDefaultConfig() component.Config {
+	clientConfig := confighttp.NewDefaultClientConfig()
+	clientConfig.Timeout = 5 * time.Second
+
+	return &Config{
+		ClientConfig: clientConfig,
+		QueueConfig:  configoptional.Some(exporterhelper.NewDefaultQueueConfig()),
+		RetryConfig:  configretry.NewDefaultBackOffConfig(),
+		Encoding:     EncodingJSON,
+	}
+}
+
+func createTraces(ctx context.Context, set exporter.Settings, cfg component.Config) (exporter.Traces, error) {
+	zCfg := cfg.(*Config)
+
+	spansURL, err := composeSpansURL(zCfg.ClientConfig.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if zCfg.Encoding == "" {
+		zCfg.Encoding = EncodingJSON
+	}
+	if zCfg.Encoding != EncodingJSON && zCfg.Encoding != EncodingProto {
+		return nil, fmt.Errorf("invalid encoding: %s", zCfg.Encoding)
+	}
+
+	ze := &zipkinExporter{
+		config:   zCfg,
+		spansURL: spansURL,
+		settings: set.TelemetrySettings,
+	}
+
+	return exporterhelper.NewTraces(ctx, set, cfg,
+		ze.pushTraces,
+		exporterhelper.WithStart(ze.start),
+		exporterhelper.WithCapabilities(consumer.Capabilities{MutatesData: false}),
+		// explicitly disable since we rely on http.Client timeout logic.
+		exporterhelper.WithTimeout(exporterhelper.TimeoutConfig{Timeout: 0}),
+		exporterhelper.WithRetry(zCfg.RetryConfig),
+		exporterhelper.WithQueue(zCfg.QueueConfig),
+	)
+}
+
+// composeSpansURL appends the path of the Zipkin v2 spans API to the endpoint unless it already contains a path.
+func composeSpansURL(endpoint string) (string, error) {
+	if endpoint == "" {
+		return "", errors.New("endpoint must be specified")
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("endpoint must be a valid URL: %w", err)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = spansPath
+	}
+	return u.String(), nil
+}
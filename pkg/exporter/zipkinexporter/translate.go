@@ -0,0 +1,211 @@
+package zipkinexporter
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"strconv"
+
+	zipkinmodel "github.com/openzipkin/zipkin-go/model"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const (
+	attrServiceName   = "service.name"
+	attrPeerService   = "peer.service"
+	attrServerAddress = "server.address"
+	attrServerPort    = "server.port"
+	attrNetPeerName   = "net.peer.name"
+	attrNetPeerPort   = "net.peer.port"
+	attrNetPeerAddr   = "net.sock.peer.addr"
+	attrNetHostAddr   = "net.sock.host.addr"
+	attrNetHostPort   = "net.host.port"
+
+	tagError             = "error"
+	tagStatusCode        = "otel.status_code"
+	tagStatusDescription = "otel.status_description"
+	tagScopeName         = "otel.scope.name"
+	tagScopeVersion      = "otel.scope.version"
+)
+
+// FromTraces converts traces into Zipkin v2 spans. Resource and span attributes become tags, events become
+// annotations. Links can't be represented in Zipkin and are dropped.
+func FromTraces(td ptrace.Traces) []*zipkinmodel.SpanModel {
+	spans := make([]*zipkinmodel.SpanModel, 0, td.SpanCount())
+
+	for i := 0; i < td.ResourceSpans().Len(); i++ {
+		rs := td.ResourceSpans().At(i)
+		resAttrs := rs.Resource().Attributes()
+
+		serviceName := "unknown_service"
+		if v, found := resAttrs.Get(attrServiceName); found {
+			serviceName = v.AsString()
+		}
+
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			ss := rs.ScopeSpans().At(j)
+			for k := 0; k < ss.Spans().Len(); k++ {
+				spans = append(spans, fromSpan(ss.Spans().At(k), ss.Scope(), resAttrs, serviceName))
+			}
+		}
+	}
+
+	return spans
+}
+
+func fromSpan(span ptrace.Span, scope pcommon.InstrumentationScope, resAttrs pcommon.Map, serviceName string) *zipkinmodel.SpanModel {
+	zs := &zipkinmodel.SpanModel{
+		SpanContext: zipkinmodel.SpanContext{
+			TraceID: traceID(span.TraceID()),
+			ID:      spanID(span.SpanID()),
+		},
+		Name:      span.Name(),
+		Kind:      kind(span.Kind()),
+		Timestamp: span.StartTimestamp().AsTime(),
+		Duration:  span.EndTimestamp().AsTime().Sub(span.StartTimestamp().AsTime()),
+		Tags:      make(map[string]string, resAttrs.Len()+span.Attributes().Len()+4),
+	}
+	if !span.ParentSpanID().IsEmpty() {
+		parentID := spanID(span.ParentSpanID())
+		zs.ParentID = &parentID
+	}
+
+	resAttrs.Range(func(k string, v pcommon.Value) bool {
+		if k != attrServiceName {
+			zs.Tags[k] = v.AsString()
+		}
+		return true
+	})
+	span.Attributes().Range(func(k string, v pcommon.Value) bool {
+		zs.Tags[k] = v.AsString()
+		return true
+	})
+
+	if scope.Name() != "" {
+		zs.Tags[tagScopeName] = scope.Name()
+	}
+	if scope.Version() != "" {
+		zs.Tags[tagScopeVersion] = scope.Version()
+	}
+
+	switch span.Status().Code() {
+	case ptrace.StatusCodeError:
+		zs.Tags[tagStatusCode] = "ERROR"
+		zs.Tags[tagError] = "true"
+		if msg := span.Status().Message(); msg != "" {
+			zs.Tags[tagError] = msg
+			zs.Tags[tagStatusDescription] = msg
+		}
+	case ptrace.StatusCodeOk:
+		zs.Tags[tagStatusCode] = "OK"
+	}
+
+	zs.LocalEndpoint = &zipkinmodel.Endpoint{ServiceName: serviceName}
+	if ip := net.ParseIP(stringAttribute(span.Attributes(), attrNetHostAddr)); ip != nil {
+		setIP(zs.LocalEndpoint, ip)
+		zs.LocalEndpoint.Port = portAttribute(span.Attributes(), attrNetHostPort)
+	}
+	zs.RemoteEndpoint = remoteEndpoint(span)
+
+	for i := 0; i < span.Events().Len(); i++ {
+		zs.Annotations = append(zs.Annotations, annotation(span.Events().At(i)))
+	}
+
+	return zs
+}
+
+// remoteEndpoint describes the called service of client and producer spans.
+func remoteEndpoint(span ptrace.Span) *zipkinmodel.Endpoint {
+	if span.Kind() != ptrace.SpanKindClient && span.Kind() != ptrace.SpanKindProducer {
+		return nil
+	}
+	attrs := span.Attributes()
+
+	var ep zipkinmodel.Endpoint
+	for _, k := range []string{attrPeerService, attrServerAddress, attrNetPeerName} {
+		if name := stringAttribute(attrs, k); name != "" {
+			ep.ServiceName = name
+			break
+		}
+	}
+	if ip := net.ParseIP(stringAttribute(attrs, attrNetPeerAddr)); ip != nil {
+		setIP(&ep, ip)
+	}
+	ep.Port = portAttribute(attrs, attrServerPort)
+	if ep.Port == 0 {
+		ep.Port = portAttribute(attrs, attrNetPeerPort)
+	}
+
+	if ep.ServiceName == "" && ep.IPv4 == nil && ep.IPv6 == nil {
+		return nil
+	}
+	return &ep
+}
+
+// annotation converts an event into an annotation. Event attributes are appended to the event name as JSON.
+func annotation(event ptrace.SpanEvent) zipkinmodel.Annotation {
+	value := event.Name()
+	if event.Attributes().Len() > 0 {
+		if attrs, err := json.Marshal(event.Attributes().AsRaw()); err == nil {
+			value += "|" + string(attrs)
+		}
+	}
+	return zipkinmodel.Annotation{
+		Timestamp: event.Timestamp().AsTime(),
+		Value:     value,
+	}
+}
+
+func kind(k ptrace.SpanKind) zipkinmodel.Kind {
+	switch k {
+	case ptrace.SpanKindClient:
+		return zipkinmodel.Client
+	case ptrace.SpanKindServer:
+		return zipkinmodel.Server
+	case ptrace.SpanKindProducer:
+		return zipkinmodel.Producer
+	case ptrace.SpanKindConsumer:
+		return zipkinmodel.Consumer
+	default:
+		return zipkinmodel.Undetermined
+	}
+}
+
+func traceID(id pcommon.TraceID) zipkinmodel.TraceID {
+	return zipkinmodel.TraceID{
+		High: binary.BigEndian.Uint64(id[:8]),
+		Low:  binary.BigEndian.Uint64(id[8:]),
+	}
+}
+
+func spanID(id pcommon.SpanID) zipkinmodel.ID {
+	return zipkinmodel.ID(binary.BigEndian.Uint64(id[:]))
+}
+
+func setIP(ep *zipkinmodel.Endpoint, ip net.IP) {
+	if ip4 := ip.To4(); ip4 != nil {
+		ep.IPv4 = ip4
+	} else {
+		ep.IPv6 = ip
+	}
+}
+
+func stringAttribute(attrs pcommon.Map, key string) string {
+	if v, found := attrs.Get(key); found {
+		return v.AsString()
+	}
+	return ""
+}
+
+func portAttribute(attrs pcommon.Map, key string) uint16 {
+	v, found := attrs.Get(key)
+	if !found {
+		return 0
+	}
+	port, err := strconv.ParseUint(v.AsString(), 10, 16)
+	if err != nil {
+		return 0
+	}
+	return uint16(port)
+}
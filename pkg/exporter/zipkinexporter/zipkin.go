@@ -0,0 +1,81 @@
+package zipkinexporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	zipkinproto "github.com/openzipkin/zipkin-go/proto/zipkin_proto3"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const (
+	jsonContentType     = "application/json"
+	protobufContentType = "application/x-protobuf"
+)
+
+type zipkinExporter struct {
+	config   *Config
+	spansURL string
+	settings component.TelemetrySettings
+	client   *http.Client
+}
+
+// start creates the HTTP client. The client construction is deferred till this point as this is the only place
+// we get hold of extensions which are required to construct auth round tripper.
+func (ze *zipkinExporter) start(ctx context.Context, host component.Host) error {
+	client, err := ze.config.ClientConfig.ToClient(ctx, host.GetExtensions(), ze.settings)
+	if err != nil {
+		return err
+	}
+	ze.client = client
+	return nil
+}
+
+func (ze *zipkinExporter) pushTraces(ctx context.Context, td ptrace.Traces) error {
+	spans := FromTraces(td)
+
+	var (
+		body        []byte
+		contentType string
+		err         error
+	)
+	switch ze.config.Encoding {
+	case EncodingProto:
+		body, err = zipkinproto.SpanSerializer{}.Serialize(spans)
+		contentType = protobufContentType
+	default:
+		body, err = json.Marshal(spans)
+		contentType = jsonContentType
+	}
+	if err != nil {
+		return consumererror.NewPermanent(fmt.Errorf("failed to serialize zipkin spans: %w", err))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ze.spansURL, bytes.NewReader(body))
+	if err != nil {
+		return consumererror.NewPermanent(err)
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := ze.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push zipkin spans: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		err = fmt.Errorf("failed to push zipkin spans: %s responded with HTTP status %s", ze.spansURL, resp.Status)
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return consumererror.NewPermanent(err)
+		}
+		return err
+	}
+	return nil
+}
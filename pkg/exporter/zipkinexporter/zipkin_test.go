@@ -0,0 +1,150 @@
+package zipkinexporter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	zipkinmodel "github.com/openzipkin/zipkin-go/model"
+	zipkinproto "github.com/openzipkin/zipkin-go/proto/zipkin_proto3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/config/configoptional"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/exporter/exporterhelper"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestZipkinExporter_PushTraces(t *testing.T) {
+	for _, encoding := range []string{EncodingJSON, EncodingProto} {
+		t.Run(encoding, func(t *testing.T) {
+			var received []*zipkinmodel.SpanModel
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, spansPath, r.URL.Path)
+				assert.Equal(t, "test-value", r.Header.Get("X-Test"))
+
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				switch encoding {
+				case EncodingProto:
+					assert.Equal(t, protobufContentType, r.Header.Get("Content-Type"))
+					received, err = zipkinproto.ParseSpans(body, false)
+				default:
+					assert.Equal(t, jsonContentType, r.Header.Get("Content-Type"))
+					err = json.Unmarshal(body, &received)
+				}
+				require.NoError(t, err)
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			defer server.Close()
+
+			exp := newTestExporter(t, server.URL, encoding)
+			require.NoError(t, exp.ConsumeTraces(context.Background(), testTraces()))
+
+			require.Len(t, received, 2)
+			assert.Equal(t, "client", received[0].Name)
+			assert.Equal(t, zipkinmodel.Client, received[0].Kind)
+			assert.Equal(t, "test-service", received[0].LocalEndpoint.ServiceName)
+			assert.Equal(t, "backend.local", received[0].RemoteEndpoint.ServiceName)
+			assert.Equal(t, uint16(8080), received[0].RemoteEndpoint.Port)
+			assert.Equal(t, "GET", received[0].Tags["http.request.method"])
+			assert.Equal(t, "true", received[0].Tags["k6"])
+			assert.Equal(t, "Internal Server Error", received[0].Tags[tagError])
+			assert.Equal(t, 100*time.Millisecond, received[0].Duration)
+			require.Len(t, received[0].Annotations, 1)
+			assert.Equal(t, `exception|{"exception.message":"boom"}`, received[0].Annotations[0].Value)
+
+			assert.Equal(t, zipkinmodel.Server, received[1].Kind)
+			require.NotNil(t, received[1].ParentID)
+			assert.Equal(t, received[0].ID, *received[1].ParentID)
+			assert.Equal(t, received[0].TraceID, received[1].TraceID)
+		})
+	}
+}
+
+func TestZipkinExporter_PushTracesError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	exp := newTestExporter(t, server.URL, EncodingJSON)
+	assert.Error(t, exp.ConsumeTraces(context.Background(), testTraces()))
+}
+
+func TestComposeSpansURL(t *testing.T) {
+	for endpoint, expected := range map[string]string{
+		"localhost:9411":                       "http://localhost:9411/api/v2/spans",
+		"https://zipkin.example.com/":          "https://zipkin.example.com/api/v2/spans",
+		"http://localhost:9411/custom/v2/path": "http://localhost:9411/custom/v2/path",
+	} {
+		u, err := composeSpansURL(endpoint)
+		require.NoError(t, err)
+		assert.Equal(t, expected, u)
+	}
+
+	_, err := composeSpansURL("")
+	assert.Error(t, err)
+}
+
+func newTestExporter(t *testing.T, endpoint, encoding string) exporter.Traces {
+	t.Helper()
+
+	factory := NewFactory()
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.ClientConfig.Endpoint = endpoint
+	cfg.ClientConfig.Headers.Set("X-Test", "test-value")
+	cfg.Encoding = encoding
+	cfg.QueueConfig = configoptional.None[exporterhelper.QueueBatchConfig]()
+	cfg.RetryConfig.Enabled = false
+
+	exp, err := factory.CreateTraces(context.Background(), exportertest.NewNopSettings(componentType), cfg)
+	require.NoError(t, err)
+	require.NoError(t, exp.Start(context.Background(), componenttest.NewNopHost()))
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(context.Background())) })
+	return exp
+}
+
+func testTraces() ptrace.Traces {
+	traces := ptrace.NewTraces()
+	rs := traces.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr(attrServiceName, "test-service")
+	rs.Resource().Attributes().PutStr("k6", "true")
+	ss := rs.ScopeSpans().AppendEmpty()
+
+	start := time.Now()
+	client := ss.Spans().AppendEmpty()
+	client.SetTraceID(pcommon.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+	client.SetSpanID(pcommon.SpanID{1, 2, 3, 4, 5, 6, 7, 8})
+	client.SetName("client")
+	client.SetKind(ptrace.SpanKindClient)
+	client.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+	client.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(100 * time.Millisecond)))
+	client.Attributes().PutStr("http.request.method", "GET")
+	client.Attributes().PutStr(attrServerAddress, "backend.local")
+	client.Attributes().PutInt(attrServerPort, 8080)
+	client.Status().SetCode(ptrace.StatusCodeError)
+	client.Status().SetMessage("Internal Server Error")
+	event := client.Events().AppendEmpty()
+	event.SetName("exception")
+	event.SetTimestamp(pcommon.NewTimestampFromTime(start.Add(50 * time.Millisecond)))
+	event.Attributes().PutStr("exception.message", "boom")
+
+	server := ss.Spans().AppendEmpty()
+	server.SetTraceID(client.TraceID())
+	server.SetSpanID(pcommon.SpanID{8, 7, 6, 5, 4, 3, 2, 1})
+	server.SetParentSpanID(client.SpanID())
+	server.SetName("server")
+	server.SetKind(ptrace.SpanKindServer)
+	server.SetStartTimestamp(pcommon.NewTimestampFromTime(start.Add(10 * time.Millisecond)))
+	server.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(90 * time.Millisecond)))
+
+	return traces
+}
@@ -0,0 +1,395 @@
+// Package mockreceiver provides an in-process OTLP receiver that records all received traces. The behavior of the
+// receiver can be changed at runtime to test clients against slow, failing or throttling backends.
+package mockreceiver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	_ "google.golang.org/grpc/encoding/gzip" // register the gzip compressor used by OTLP exporters
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http"
+
+	defaultEndpoint = "127.0.0.1:0"
+	tracesPath      = "/v1/traces"
+	shutdownTimeout = 5 * time.Second
+)
+
+// Config contains the addresses the receiver listens on. Empty addresses use a random free port on localhost.
+type Config struct {
+	GRPCEndpoint string `js:"grpc_endpoint"`
+	HTTPEndpoint string `js:"http_endpoint"`
+}
+
+// Request is a received OTLP export request.
+type Request struct {
+	Protocol string
+	// Headers contains the HTTP headers or the gRPC metadata with lower case names.
+	Headers map[string][]string
+	Traces  ptrace.Traces
+	Time    time.Time
+	// Status is the HTTP status code of the response, failed gRPC requests are recorded with the equivalent HTTP
+	// status code.
+	Status int
+}
+
+// Receiver is an OTLP gRPC and HTTP receiver that records all requests.
+type Receiver struct {
+	grpcServer   *grpc.Server
+	grpcListener net.Listener
+	httpServer   *http.Server
+	httpListener net.Listener
+
+	mu       sync.Mutex
+	requests []Request
+	latency  time.Duration
+	failures []failure
+}
+
+// failure is the response to one or more of the next requests.
+type failure struct {
+	status int
+	// throttle whether the client is asked to retry after retryAfter
+	throttle   bool
+	retryAfter time.Duration
+	// remaining is the number of requests that fail, a negative value fails all requests until the receiver is
+	// reset.
+	remaining int
+}
+
+// Start starts a receiver that listens on the addresses of cfg.
+func Start(cfg *Config) (*Receiver, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	grpcListener, err := net.Listen("tcp", endpointOrDefault(cfg.GRPCEndpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for gRPC: %w", err)
+	}
+	httpListener, err := net.Listen("tcp", endpointOrDefault(cfg.HTTPEndpoint))
+	if err != nil {
+		_ = grpcListener.Close()
+		return nil, fmt.Errorf("failed to listen for HTTP: %w", err)
+	}
+
+	r := &Receiver{grpcListener: grpcListener, httpListener: httpListener}
+	r.grpcServer = grpc.NewServer()
+	ptraceotlp.RegisterGRPCServer(r.grpcServer, &grpcHandler{receiver: r})
+	mux := http.NewServeMux()
+	mux.HandleFunc(tracesPath, r.handleHTTP)
+	r.httpServer = &http.Server{Handler: mux, ReadHeaderTimeout: shutdownTimeout}
+
+	go func() { _ = r.grpcServer.Serve(grpcListener) }()
+	go func() { _ = r.httpServer.Serve(httpListener) }()
+	return r, nil
+}
+
+// Stop stops both servers, requests that are in flight are completed.
+func (r *Receiver) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		r.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	err := r.httpServer.Shutdown(ctx)
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		r.grpcServer.Stop()
+	}
+	return err
+}
+
+// GRPCEndpoint returns the address of the gRPC server, e.g. 127.0.0.1:4317.
+func (r *Receiver) GRPCEndpoint() string {
+	return r.grpcListener.Addr().String()
+}
+
+// HTTPEndpoint returns the address of the HTTP server, traces are received on the path /v1/traces.
+func (r *Receiver) HTTPEndpoint() string {
+	return r.httpListener.Addr().String()
+}
+
+// SetLatency delays all responses by d.
+func (r *Receiver) SetLatency(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latency = d
+}
+
+// Fail responds to the next count requests with the HTTP status code, gRPC requests fail with the equivalent gRPC
+// code. If count is not positive, all requests fail until Reset is called.
+func (r *Receiver) Fail(statusCode, count int) {
+	r.addFailure(failure{status: statusCode, remaining: remaining(count)})
+}
+
+// Throttle rejects the next count requests with 429 Too Many Requests or RESOURCE_EXHAUSTED and asks the client
+// to retry after retryAfter. If count is not positive, all requests are throttled until Reset is called.
+func (r *Receiver) Throttle(retryAfter time.Duration, count int) {
+	r.addFailure(failure{status: http.StatusTooManyRequests, throttle: true, retryAfter: retryAfter, remaining: remaining(count)})
+}
+
+// Reset removes all recorded requests, the latency and all failures.
+func (r *Receiver) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = nil
+	r.latency = 0
+	r.failures = nil
+}
+
+// Requests returns all recorded requests, including the failed ones.
+func (r *Receiver) Requests() []Request {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Request(nil), r.requests...)
+}
+
+// Traces returns the traces of all successful requests.
+func (r *Receiver) Traces() ptrace.Traces {
+	traces := ptrace.NewTraces()
+	for _, req := range r.Requests() {
+		if req.Status != http.StatusOK {
+			continue
+		}
+		for i := 0; i < req.Traces.ResourceSpans().Len(); i++ {
+			req.Traces.ResourceSpans().At(i).CopyTo(traces.ResourceSpans().AppendEmpty())
+		}
+	}
+	return traces
+}
+
+// SpanCount returns the number of spans of all successful requests.
+func (r *Receiver) SpanCount() int {
+	var n int
+	for _, req := range r.Requests() {
+		if req.Status == http.StatusOK {
+			n += req.Traces.SpanCount()
+		}
+	}
+	return n
+}
+
+// TraceCount returns the number of distinct trace ids of all successful requests.
+func (r *Receiver) TraceCount() int {
+	ids := make(map[pcommon.TraceID]struct{})
+	for _, req := range r.Requests() {
+		if req.Status != http.StatusOK {
+			continue
+		}
+		for i := 0; i < req.Traces.ResourceSpans().Len(); i++ {
+			ss := req.Traces.ResourceSpans().At(i).ScopeSpans()
+			for j := 0; j < ss.Len(); j++ {
+				for k := 0; k < ss.At(j).Spans().Len(); k++ {
+					ids[ss.At(j).Spans().At(k).TraceID()] = struct{}{}
+				}
+			}
+		}
+	}
+	return len(ids)
+}
+
+// HeaderValues returns the values of a header of all requests. Header names are case-insensitive.
+func (r *Receiver) HeaderValues(name string) []string {
+	var values []string
+	for _, req := range r.Requests() {
+		values = append(values, metadata.MD(req.Headers).Get(name)...)
+	}
+	return values
+}
+
+// receive records a request and returns the status it is answered with after the latency.
+func (r *Receiver) receive(ctx context.Context, req Request) failure {
+	r.mu.Lock()
+	latency := r.latency
+	result := failure{status: http.StatusOK}
+	if len(r.failures) > 0 {
+		result = r.failures[0]
+		if r.failures[0].remaining > 0 {
+			r.failures[0].remaining--
+			if r.failures[0].remaining == 0 {
+				r.failures = r.failures[1:]
+			}
+		}
+	}
+	req.Status = result.status
+	r.requests = append(r.requests, req)
+	r.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-ctx.Done():
+		}
+	}
+	return result
+}
+
+func (r *Receiver) addFailure(f failure) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failures = append(r.failures, f)
+}
+
+func (r *Receiver) handleHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := readBody(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	contentType := req.Header.Get("Content-Type")
+	exportRequest := ptraceotlp.NewExportRequest()
+	if contentType == "application/json" {
+		err = exportRequest.UnmarshalJSON(body)
+	} else {
+		err = exportRequest.UnmarshalProto(body)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	headers := metadata.MD{}
+	for k, v := range req.Header {
+		headers.Append(k, v...)
+	}
+	result := r.receive(req.Context(), Request{
+		Protocol: ProtocolHTTP,
+		Headers:  headers,
+		Traces:   exportRequest.Traces(),
+		Time:     time.Now(),
+	})
+	if result.status != http.StatusOK {
+		if result.throttle {
+			w.Header().Set("Retry-After", strconv.Itoa(int(result.retryAfter.Round(time.Second).Seconds())))
+		}
+		http.Error(w, http.StatusText(result.status), result.status)
+		return
+	}
+
+	var resp []byte
+	if contentType == "application/json" {
+		resp, err = ptraceotlp.NewExportResponse().MarshalJSON()
+	} else {
+		resp, err = ptraceotlp.NewExportResponse().MarshalProto()
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	_, _ = w.Write(resp)
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	if req.Header.Get("Content-Encoding") != "gzip" {
+		return body, nil
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(gz)
+}
+
+type grpcHandler struct {
+	ptraceotlp.UnimplementedGRPCServer
+	receiver *Receiver
+}
+
+func (h *grpcHandler) Export(ctx context.Context, req ptraceotlp.ExportRequest) (ptraceotlp.ExportResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	// the request is reused by the server after Export returns
+	traces := ptrace.NewTraces()
+	req.Traces().CopyTo(traces)
+
+	result := h.receiver.receive(ctx, Request{
+		Protocol: ProtocolGRPC,
+		Headers:  md.Copy(),
+		Traces:   traces,
+		Time:     time.Now(),
+	})
+	if result.status == http.StatusOK {
+		return ptraceotlp.NewExportResponse(), nil
+	}
+
+	st := status.New(grpcCode(result.status), http.StatusText(result.status))
+	if result.throttle {
+		withDetails, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(result.retryAfter)})
+		if err != nil {
+			return ptraceotlp.NewExportResponse(), errors.Join(st.Err(), err)
+		}
+		st = withDetails
+	}
+	return ptraceotlp.NewExportResponse(), st.Err()
+}
+
+// grpcCode maps HTTP status codes to gRPC codes like the OTLP receiver of the collector does in reverse.
+func grpcCode(statusCode int) codes.Code {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusInternalServerError:
+		return codes.Internal
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return codes.Unavailable
+	default:
+		return codes.Unknown
+	}
+}
+
+func endpointOrDefault(endpoint string) string {
+	if endpoint == "" {
+		return defaultEndpoint
+	}
+	return endpoint
+}
+
+func remaining(count int) int {
+	if count <= 0 {
+		return -1
+	}
+	return count
+}
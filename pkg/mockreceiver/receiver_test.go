@@ -0,0 +1,160 @@
+package mockreceiver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestReceiver_GRPC(t *testing.T) {
+	r := startTestReceiver(t)
+	client := grpcClient(t, r)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "X-Scope-OrgID", "tenant-1")
+	_, err := client.Export(ctx, ptraceotlp.NewExportRequestFromTraces(testTraces(2)))
+	require.NoError(t, err)
+	_, err = client.Export(ctx, ptraceotlp.NewExportRequestFromTraces(testTraces(1)))
+	require.NoError(t, err)
+
+	assert.Len(t, r.Requests(), 2)
+	assert.Equal(t, ProtocolGRPC, r.Requests()[0].Protocol)
+	assert.Equal(t, 3, r.SpanCount())
+	assert.Equal(t, 3, r.TraceCount())
+	assert.Equal(t, 3, r.Traces().SpanCount())
+	assert.Equal(t, []string{"tenant-1", "tenant-1"}, r.HeaderValues("x-scope-orgid"))
+
+	r.Reset()
+	assert.Empty(t, r.Requests())
+	assert.Equal(t, 0, r.SpanCount())
+}
+
+func TestReceiver_GRPCFailures(t *testing.T) {
+	r := startTestReceiver(t)
+	client := grpcClient(t, r)
+	export := func() error {
+		_, err := client.Export(context.Background(), ptraceotlp.NewExportRequestFromTraces(testTraces(1)))
+		return err
+	}
+
+	r.Fail(http.StatusServiceUnavailable, 2)
+	r.Throttle(3*time.Second, 1)
+	assert.Equal(t, codes.Unavailable, status.Code(export()))
+	assert.Equal(t, codes.Unavailable, status.Code(export()))
+
+	err := export()
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	details := status.Convert(err).Details()
+	require.Len(t, details, 1)
+	assert.Equal(t, 3*time.Second, details[0].(*errdetails.RetryInfo).GetRetryDelay().AsDuration())
+
+	require.NoError(t, export())
+	assert.Equal(t, 1, r.SpanCount())
+	require.Len(t, r.Requests(), 4)
+	assert.Equal(t, http.StatusTooManyRequests, r.Requests()[2].Status)
+
+	r.Fail(http.StatusBadRequest, 0)
+	for range 3 {
+		assert.Equal(t, codes.InvalidArgument, status.Code(export()))
+	}
+	r.Reset()
+	require.NoError(t, export())
+}
+
+func TestReceiver_HTTP(t *testing.T) {
+	r := startTestReceiver(t)
+	url := "http://" + r.HTTPEndpoint() + "/v1/traces"
+
+	data, err := ptraceotlp.NewExportRequestFromTraces(testTraces(2)).MarshalProto()
+	require.NoError(t, err)
+	var body bytes.Buffer
+	gz := gzip.NewWriter(&body)
+	_, err = gz.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	req, err := http.NewRequest(http.MethodPost, url, &body)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("X-Scope-OrgID", "tenant-1")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	data, err = ptraceotlp.NewExportRequestFromTraces(testTraces(1)).MarshalJSON()
+	require.NoError(t, err)
+	r.Throttle(2*time.Second, 1)
+	resp, err = http.Post(url, "application/json", bytes.NewReader(data)) //nolint:noctx // test request
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "2", resp.Header.Get("Retry-After"))
+
+	assert.Equal(t, 2, r.SpanCount())
+	assert.Len(t, r.Requests(), 2)
+	assert.Equal(t, ProtocolHTTP, r.Requests()[0].Protocol)
+	assert.Equal(t, []string{"tenant-1"}, r.HeaderValues("X-Scope-OrgID"))
+}
+
+func TestReceiver_Latency(t *testing.T) {
+	r := startTestReceiver(t)
+	client := grpcClient(t, r)
+
+	r.SetLatency(100 * time.Millisecond)
+	start := time.Now()
+	_, err := client.Export(context.Background(), ptraceotlp.NewExportRequestFromTraces(testTraces(1)))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
+
+func startTestReceiver(t *testing.T) *Receiver {
+	t.Helper()
+
+	r, err := Start(nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = r.Stop() })
+	return r
+}
+
+func grpcClient(t *testing.T, r *Receiver) ptraceotlp.GRPCClient {
+	t.Helper()
+
+	conn, err := grpc.NewClient(r.GRPCEndpoint(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return ptraceotlp.NewGRPCClient(conn)
+}
+
+// testTraceID is incremented for every span created by testTraces.
+var testTraceID atomic.Uint32
+
+// testTraces returns traces with n spans that belong to different traces.
+func testTraces(n int) ptrace.Traces {
+	traces := ptrace.NewTraces()
+	spans := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	for range n {
+		var id [16]byte
+		binary.BigEndian.PutUint32(id[12:], testTraceID.Add(1))
+		span := spans.AppendEmpty()
+		span.SetTraceID(id)
+		span.SetSpanID([8]byte{1})
+	}
+	return traces
+}
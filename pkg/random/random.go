@@ -6,158 +6,360 @@ import (
 	"fmt"
 	"math/rand/v2"
 	"net/http"
-	"sync"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.opentelemetry.io/collector/pdata/pcommon"
 )
 
+const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
 var (
-	letters             = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
 	httpStatusesSuccess = []int64{200, 201, 202, 204}
-	httpStatusesError   = []int64{400, 401, 403, 404, 405, 406, 408, 409, 410, 411, 412, 413, 414, 415, 417, 428, 427, 500, 501, 502}
-	httpMethods         = []string{http.MethodGet, http.MethodDelete, http.MethodPost, http.MethodPut, http.MethodPatch}
-	httpContentTypes    = []string{"application/json", "application/xml", "application/x-www-form-urlencoded", "text/plain", "text/html"}
-	operations          = []string{"get", "list", "query", "search", "set", "add", "create", "update", "send", "remove", "delete"}
-	serviceSuffix       = []string{"", "", "service", "backend", "api", "proxy", "engine"}
-	dbNames             = []string{"redis", "mysql", "postgres", "memcached", "mongodb", "elasticsearch"}
-	resources           = []string{
+	httpStatusesError   = []int64{400, 401, 403, 404, 405, 406, 408, 409, 410, 411, 412, 413, 414, 415, 417, 428, 427, 500, 501, 502, 503, 504}
+	grpcStatusesError   = []int64{2, 4, 8, 13, 14} // Unknown, DeadlineExceeded, ResourceExhausted, Internal, Unavailable
+	dbErrorCodes        = map[string][]string{
+		"postgresql": {"40P01", "57014", "53300", "08006"},
+		"mysql":      {"1205", "1213", "1040", "2013"},
+		"mongodb":    {"50", "91", "11000", "11600"},
+		"redis":      {"ERR", "BUSY", "OOM", "LOADING"},
+	}
+	httpMethods        = []string{http.MethodGet, http.MethodDelete, http.MethodPost, http.MethodPut, http.MethodPatch}
+	httpContentTypes   = []string{"application/json", "application/xml", "application/x-www-form-urlencoded", "text/plain", "text/html"}
+	operations         = []string{"get", "list", "query", "search", "set", "add", "create", "update", "send", "remove", "delete"}
+	serviceSuffix      = []string{"", "", "service", "backend", "api", "proxy", "engine"}
+	dbNames            = []string{"redis", "mysql", "postgres", "memcached", "mongodb", "elasticsearch"}
+	dbSystems          = map[string]string{"postgres": "postgresql"}
+	dbPorts            = map[string]int{"redis": 6379, "mysql": 3306, "postgres": 5432, "memcached": 11211, "mongodb": 27017, "elasticsearch": 9200}
+	dbNamespaces       = []string{"shop", "store", "inventory", "customers", "billing", "analytics"}
+	sqlOperations      = []string{"SELECT", "SELECT", "SELECT", "INSERT", "UPDATE", "DELETE"}
+	keyValueOperations = []string{"GET", "GET", "SET", "DEL", "EXPIRE"}
+	documentOperations = []string{"find", "find", "insert", "update", "delete"}
+	searchOperations   = []string{"search", "search", "index", "delete"}
+	messagingSystems   = []string{"kafka", "kafka", "rabbitmq", "activemq", "pulsar", "aws_sqs"}
+	messagingPorts     = map[string]int{"kafka": 9092, "rabbitmq": 5672, "activemq": 61616, "pulsar": 6650, "aws_sqs": 443}
+	resources          = []string{
 		"order", "payment", "customer", "product", "stock", "inventory",
 		"shipping", "billing", "checkout", "cart", "search", "analytics"}
-
-	// rnd contains rand.Rand instance protected by a mutex
-	rnd = struct {
-		sync.Mutex
-		*rand.Rand
-	}{}
 )
 
-func init() {
-	var seed [32]byte
+// Source generates random values. A Source is not safe for concurrent use and is meant to be owned by a single
+// generator, which in turn is owned by a single VU. Therefore, no locking is required and the generation of
+// random values scales with the number of VUs. A Source created with NewSource or reseeded with Seed produces
+// the same sequence of values for the same seed.
+type Source struct {
+	pcg *rand.PCG
+	rnd *rand.Rand
+}
+
+// NewSource creates a Source with the given seed.
+func NewSource(seed uint64) *Source {
+	pcg := rand.NewPCG(0, 0)
+	s := &Source{pcg: pcg, rnd: rand.New(pcg)}
+	s.Seed(seed)
+	return s
+}
+
+// NewRandomSource creates a Source with a seed from crypto/rand.
+func NewRandomSource() *Source {
+	var seed [8]byte
 	_, err := crand.Read(seed[:])
 	if err != nil {
 		panic(err)
 	}
-	rnd.Rand = rand.New(rand.NewChaCha8(seed))
+	return NewSource(binary.BigEndian.Uint64(seed[:]))
+}
+
+// Seed resets the Source to the state defined by the seed.
+func (s *Source) Seed(seed uint64) {
+	s.pcg.Seed(seed, DeriveSeed(seed))
+}
+
+// DeriveSeed combines a seed with additional values like a VU ID and an iteration into a new seed. The values
+// are mixed using SplitMix64, so that seeds that differ in a single bit lead to unrelated sequences.
+func DeriveSeed(seed uint64, values ...uint64) uint64 {
+	h := splitMix64(seed)
+	for _, v := range values {
+		h = splitMix64(h ^ v)
+	}
+	return h
+}
+
+func splitMix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
 }
 
-func Float32() float32 {
-	rnd.Lock()
-	defer rnd.Unlock()
-	return rnd.Float32()
+// Pick returns a random element of elements using the given Source.
+func Pick[T any](s *Source, elements []T) T {
+	return elements[s.rnd.IntN(len(elements))]
 }
 
-func IntN(n int) int {
-	rnd.Lock()
-	defer rnd.Unlock()
-	return rnd.IntN(n)
+func (s *Source) Float32() float32 {
+	return s.rnd.Float32()
 }
 
-func SelectElement[T any](elements []T) T {
-	rnd.Lock()
-	defer rnd.Unlock()
-	return elements[rnd.IntN(len(elements))]
+func (s *Source) Float64() float64 {
+	return s.rnd.Float64()
 }
 
-func String(n int) string {
-	s := make([]rune, n)
-	for i := range s {
-		s[i] = SelectElement(letters)
+// NormFloat64 returns a normally distributed value with mean 0 and standard deviation 1.
+func (s *Source) NormFloat64() float64 {
+	return s.rnd.NormFloat64()
+}
+
+// ExpFloat64 returns an exponentially distributed value with mean 1.
+func (s *Source) ExpFloat64() float64 {
+	return s.rnd.ExpFloat64()
+}
+
+func (s *Source) IntN(n int) int {
+	return s.rnd.IntN(n)
+}
+
+func (s *Source) String(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = letters[s.rnd.IntN(len(letters))]
 	}
-	return string(s)
+	return string(b)
 }
 
-func K6String(n int) string {
-	return "k6." + String(n)
+func (s *Source) K6String(n int) string {
+	return "k6." + s.String(n)
 }
 
-func IntBetween(min, max int) int {
-	rnd.Lock()
-	defer rnd.Unlock()
-	n := rnd.IntN(max - min)
+func (s *Source) IntBetween(min, max int) int {
+	n := s.rnd.IntN(max - min)
 	return min + n
 }
 
-func Duration(min, max time.Duration) time.Duration {
-	rnd.Lock()
-	defer rnd.Unlock()
-	n := rnd.Int64N(int64(max) - int64(min))
+// Duration returns a random duration in [min, max), or min if the range is empty.
+func (s *Source) Duration(min, max time.Duration) time.Duration {
+	if max <= min {
+		return min
+	}
+	n := s.rnd.Int64N(int64(max) - int64(min))
 	return min + time.Duration(n)
 }
 
-func IPAddr() string {
-	rnd.Lock()
-	defer rnd.Unlock()
-	return fmt.Sprintf("192.168.%d.%d", rnd.IntN(255), rnd.IntN(255))
+func (s *Source) IPAddr() string {
+	return fmt.Sprintf("192.168.%d.%d", s.rnd.IntN(255), s.rnd.IntN(255))
+}
+
+func (s *Source) Port() int {
+	return s.IntBetween(8000, 9000)
+}
+
+func (s *Source) HTTPStatusSuccess() int64 {
+	return Pick(s, httpStatusesSuccess)
+}
+
+func (s *Source) HTTPStatusErr() int64 {
+	return Pick(s, httpStatusesError)
 }
 
-func Port() int {
-	return IntBetween(8000, 9000)
+// HTTPStatusServerErr returns a random status code of a server error.
+func (s *Source) HTTPStatusServerErr() int64 {
+	i := slices.IndexFunc(httpStatusesError, func(status int64) bool { return status >= 500 })
+	return Pick(s, httpStatusesError[i:])
 }
 
-func HTTPStatusSuccess() int64 {
-	return SelectElement(httpStatusesSuccess)
+// GRPCStatusErr returns a random gRPC status code of a failed call.
+func (s *Source) GRPCStatusErr() int64 {
+	return Pick(s, grpcStatusesError)
 }
 
-func HTTPStatusErr() int64 {
-	return SelectElement(httpStatusesError)
+// DBErrorCode returns a random error code of a database system or an empty string if the system is not known.
+func (s *Source) DBErrorCode(system string) string {
+	codes, found := dbErrorCodes[system]
+	if !found {
+		return ""
+	}
+	return Pick(s, codes)
+}
+
+func (s *Source) HTTPMethod() string {
+	return Pick(s, httpMethods)
+}
+
+func (s *Source) HTTPContentType() []any {
+	return []any{Pick(s, httpContentTypes)}
+}
+
+func (s *Source) DBService() string {
+	return Pick(s, dbNames)
 }
 
-func HTTPMethod() string {
-	return SelectElement(httpMethods)
+// DBPort returns the default port for a database system.
+func (s *Source) DBPort(system string) int {
+	for name, port := range dbPorts {
+		if strings.HasPrefix(system, name) {
+			return port
+		}
+	}
+	return s.Port()
 }
 
-func HTTPContentType() []any {
-	return []any{SelectElement(httpContentTypes)}
+// DBNamespace returns a random database name that fits the given system.
+func (s *Source) DBNamespace(system string) string {
+	if system == "redis" || system == "memcached" {
+		return strconv.Itoa(s.IntN(16))
+	}
+	return Pick(s, dbNamespaces)
 }
 
-func DBService() string {
-	return SelectElement(dbNames)
+// DBCollection returns a random table or collection name.
+func (s *Source) DBCollection() string {
+	return Pick(s, resources) + "s"
 }
 
-func Service() string {
-	resource := SelectElement(resources)
-	return ServiceForResource(resource)
+// DBQuery returns a random operation name and matching query text for a database system and table or
+// collection name.
+func (s *Source) DBQuery(system, collection string) (operation, query string) {
+	key := strings.TrimSuffix(collection, "s") + ":" + strconv.Itoa(s.IntN(100_000))
+	switch system {
+	case "redis":
+		operation = Pick(s, keyValueOperations)
+		switch operation {
+		case "SET":
+			query = operation + " " + key + " ?"
+		case "EXPIRE":
+			query = operation + " " + key + " " + strconv.Itoa(s.IntBetween(60, 3600))
+		default:
+			query = operation + " " + key
+		}
+	case "memcached":
+		operation = strings.ToLower(Pick(s, keyValueOperations))
+		if operation == "del" || operation == "expire" {
+			operation = "delete"
+		}
+		query = operation + " " + key
+	case "mongodb":
+		operation = Pick(s, documentOperations)
+		switch operation {
+		case "insert":
+			query = fmt.Sprintf(`{"insert":"%s","documents":[{"_id":"?","name":"?"}]}`, collection)
+		case "update":
+			query = fmt.Sprintf(`{"update":"%s","updates":[{"q":{"_id":"?"},"u":{"$set":{"name":"?"}}}]}`, collection)
+		default:
+			query = fmt.Sprintf(`{"%s":"%s","filter":{"_id":"?"}}`, operation, collection)
+		}
+	case "elasticsearch":
+		operation = Pick(s, searchOperations)
+		switch operation {
+		case "search":
+			query = `{"query":{"match":{"name":"?"}},"size":` + strconv.Itoa(s.IntBetween(10, 100)) + "}"
+		default:
+			query = fmt.Sprintf(`{"_index":"%s","_id":"?"}`, collection)
+		}
+	default:
+		param := "?"
+		if system == "postgresql" {
+			param = "$1"
+		}
+		operation = Pick(s, sqlOperations)
+		switch operation {
+		case "INSERT":
+			query = fmt.Sprintf("INSERT INTO %s (id, name, created_at) VALUES (%s, %s, %s)", collection, param, param, param)
+		case "UPDATE":
+			query = fmt.Sprintf("UPDATE %s SET name = %s WHERE id = %s", collection, param, param)
+		case "DELETE":
+			query = fmt.Sprintf("DELETE FROM %s WHERE id = %s", collection, param)
+		default:
+			query = fmt.Sprintf("SELECT id, name, created_at FROM %s WHERE id = %s", collection, param)
+		}
+	}
+	return operation, query
 }
 
-func ServiceForResource(resource string) string {
+// MessagingService returns the name of a random messaging system.
+func (s *Source) MessagingService() string {
+	return Pick(s, messagingSystems)
+}
+
+// MessagingPort returns the default broker port for a messaging system.
+func (s *Source) MessagingPort(system string) int {
+	if port, found := messagingPorts[system]; found {
+		return port
+	}
+	return s.Port()
+}
+
+// MessageID returns a random message ID.
+func (s *Source) MessageID() string {
+	id := s.TraceID()
+	return id.String()
+}
+
+func (s *Source) Service() string {
+	resource := Pick(s, resources)
+	return s.ServiceForResource(resource)
+}
+
+func (s *Source) ServiceForResource(resource string) string {
 	name := resource
-	suffix := SelectElement(serviceSuffix)
+	suffix := Pick(s, serviceSuffix)
 	if suffix != "" {
 		name = name + "-" + suffix
 	}
 	return name
 }
 
-func Operation() string {
-	resource := SelectElement(resources)
-	return OperationForResource(resource)
+func (s *Source) Operation() string {
+	resource := Pick(s, resources)
+	return s.OperationForResource(resource)
 }
 
-func OperationForResource(resource string) string {
-	op := SelectElement(operations)
+func (s *Source) OperationForResource(resource string) string {
+	op := Pick(s, operations)
 	return op + "-" + resource
 }
 
-func TraceID() pcommon.TraceID {
-	rnd.Lock()
-	defer rnd.Unlock()
-
+func (s *Source) TraceID() pcommon.TraceID {
 	var b [16]byte
-	binary.BigEndian.PutUint64(b[:8], rnd.Uint64())
-	binary.BigEndian.PutUint64(b[8:], rnd.Uint64())
+	binary.BigEndian.PutUint64(b[:8], s.rnd.Uint64())
+	binary.BigEndian.PutUint64(b[8:], s.rnd.Uint64())
 	return b
 }
 
-func SpanID() pcommon.SpanID {
-	rnd.Lock()
-	defer rnd.Unlock()
-
+func (s *Source) SpanID() pcommon.SpanID {
 	var b [8]byte
-	binary.BigEndian.PutUint64(b[:], rnd.Uint64())
+	binary.BigEndian.PutUint64(b[:], s.rnd.Uint64())
 	return b
 }
 
-func EventName() string {
-	return "event_k6." + String(10)
+func (s *Source) EventName() string {
+	return "event_k6." + s.String(10)
+}
+
+// DBSystem returns the db.system value for a database service name, e.g. "postgresql" for "postgres-db". The
+// second return value is false if the service name does not contain a known database name.
+func DBSystem(service string) (string, bool) {
+	service = strings.ToLower(service)
+	for _, name := range dbNames {
+		if strings.Contains(service, name) {
+			if system, found := dbSystems[name]; found {
+				return system, true
+			}
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// MessagingSystem returns the messaging.system value for a service name, e.g. "kafka" for "kafka-broker". The
+// second return value is false if the service name does not contain a known messaging system.
+func MessagingSystem(service string) (string, bool) {
+	service = strings.ToLower(service)
+	for _, name := range messagingSystems {
+		if strings.Contains(service, name) {
+			return name, true
+		}
+	}
+	return "", false
 }
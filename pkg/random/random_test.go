@@ -3,7 +3,9 @@ package random
 import (
 	"fmt"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -14,12 +16,13 @@ const (
 	testRounds = 10
 )
 
-func TestSelectElement(t *testing.T) {
+func TestPick(t *testing.T) {
+	rnd := NewRandomSource()
 	var prev string
 	var eqCount int
 
 	for i := 0; i < testRounds; i++ {
-		res := SelectElement(resources)
+		res := Pick(rnd, resources)
 		if res == prev {
 			eqCount++
 		}
@@ -32,11 +35,12 @@ func TestSelectElement(t *testing.T) {
 }
 
 func TestString(t *testing.T) {
+	rnd := NewRandomSource()
 	for n := 5; n <= 20; n += 5 {
 		t.Run(fmt.Sprintf("length_%d", n), func(t *testing.T) {
 			var prev string
 			for i := 0; i < testRounds; i++ {
-				s := String(n)
+				s := rnd.String(n)
 				assert.Len(t, s, n)
 				assert.NotEqual(t, prev, s)
 				prev = s
@@ -46,11 +50,12 @@ func TestString(t *testing.T) {
 }
 
 func TestK6String(t *testing.T) {
+	rnd := NewRandomSource()
 	for n := 5; n <= 20; n += 5 {
 		t.Run(fmt.Sprintf("length_%d", n), func(t *testing.T) {
 			var prev string
 			for i := 0; i < testRounds; i++ {
-				s := K6String(n)
+				s := rnd.K6String(n)
 				assert.Len(t, s, n+3)
 				assert.Equal(t, "k6.", s[:3])
 				assert.NotEqual(t, prev, s)
@@ -61,6 +66,7 @@ func TestK6String(t *testing.T) {
 }
 
 func TestIntBetween(t *testing.T) {
+	rnd := NewRandomSource()
 	const (
 		min = 15
 		max = 25
@@ -68,7 +74,7 @@ func TestIntBetween(t *testing.T) {
 
 	var prev, eqCount int
 	for i := 0; i < testRounds; i++ {
-		n := IntBetween(min, max)
+		n := rnd.IntBetween(min, max)
 		if n == prev {
 			eqCount++
 		}
@@ -81,14 +87,58 @@ func TestIntBetween(t *testing.T) {
 	assert.Less(t, eqCount, 4, "too many equal random numbers")
 }
 
+func TestDuration(t *testing.T) {
+	rnd := NewRandomSource()
+	for i := 0; i < testRounds; i++ {
+		d := rnd.Duration(time.Second, 2*time.Second)
+		assert.GreaterOrEqual(t, d, time.Second)
+		assert.Less(t, d, 2*time.Second)
+	}
+
+	// empty ranges don't panic
+	assert.Equal(t, time.Duration(0), rnd.Duration(0, 0))
+	assert.Equal(t, time.Second, rnd.Duration(time.Second, time.Millisecond))
+}
+
 func TestDBService(t *testing.T) {
-	db := DBService()
+	rnd := NewRandomSource()
+	db := rnd.DBService()
 
 	assert.Contains(t, dbNames, db)
 }
 
+func TestDBSystem(t *testing.T) {
+	system, found := DBSystem("postgres")
+	assert.True(t, found)
+	assert.Equal(t, "postgresql", system)
+
+	system, found = DBSystem("orders-mongodb")
+	assert.True(t, found)
+	assert.Equal(t, "mongodb", system)
+
+	_, found = DBSystem("shop-backend")
+	assert.False(t, found)
+}
+
+func TestDBQuery(t *testing.T) {
+	rnd := NewRandomSource()
+	for _, name := range dbNames {
+		system, _ := DBSystem(name)
+		for i := 0; i < testRounds; i++ {
+			op, query := rnd.DBQuery(system, "orders")
+			assert.NotEmpty(t, op, "empty operation for %s", system)
+			assert.NotEmpty(t, query, "empty query for %s", system)
+		}
+	}
+
+	_, query := rnd.DBQuery("postgresql", "orders")
+	assert.Contains(t, query, "orders")
+	assert.Contains(t, query, "$1")
+}
+
 func TestOperation(t *testing.T) {
-	op := Operation()
+	rnd := NewRandomSource()
+	op := rnd.Operation()
 
 	parts := strings.Split(op, "-")
 	require.Equal(t, 2, len(parts))
@@ -97,7 +147,8 @@ func TestOperation(t *testing.T) {
 }
 
 func TestService(t *testing.T) {
-	srv := Service()
+	rnd := NewRandomSource()
+	srv := rnd.Service()
 
 	parts := strings.Split(srv, "-")
 	assert.Contains(t, resources, parts[0])
@@ -107,9 +158,10 @@ func TestService(t *testing.T) {
 }
 
 func TestSpanID(t *testing.T) {
+	rnd := NewRandomSource()
 	var prev pcommon.SpanID
 	for i := 0; i < testRounds; i++ {
-		id := SpanID()
+		id := rnd.SpanID()
 		assert.False(t, id.IsEmpty())
 		assert.NotEqual(t, prev, id)
 		prev = id
@@ -117,11 +169,75 @@ func TestSpanID(t *testing.T) {
 }
 
 func TestTraceID(t *testing.T) {
+	rnd := NewRandomSource()
 	var prev pcommon.TraceID
 	for i := 0; i < testRounds; i++ {
-		id := TraceID()
+		id := rnd.TraceID()
 		assert.False(t, id.IsEmpty())
 		assert.NotEqual(t, prev, id)
 		prev = id
 	}
 }
+
+func TestSource_Seed(t *testing.T) {
+	s1, s2 := NewSource(42), NewSource(42)
+	for i := 0; i < testRounds; i++ {
+		assert.Equal(t, s1.TraceID(), s2.TraceID())
+		assert.Equal(t, s1.String(10), s2.String(10))
+	}
+
+	s1.Seed(7)
+	id := s1.SpanID()
+	s1.Seed(7)
+	assert.Equal(t, id, s1.SpanID())
+}
+
+func TestDeriveSeed(t *testing.T) {
+	assert.Equal(t, DeriveSeed(42, 1, 2), DeriveSeed(42, 1, 2))
+	assert.NotEqual(t, DeriveSeed(42, 1, 2), DeriveSeed(42, 2, 1))
+	assert.NotEqual(t, DeriveSeed(42, 1, 2), DeriveSeed(43, 1, 2))
+	assert.NotEqual(t, DeriveSeed(42), DeriveSeed(42, 0))
+}
+
+// benchmarkOps are typical operations of a generator, each benchmark runs them concurrently on all cores.
+var benchmarkOps = []struct {
+	name string
+	op   func(s *Source)
+}{
+	{"String", func(s *Source) { s.String(30) }},
+	{"TraceID", func(s *Source) { s.TraceID() }},
+	{"Service", func(s *Source) { s.Service() }},
+	{"DBQuery", func(s *Source) { s.DBQuery("postgresql", "orders") }},
+}
+
+// BenchmarkSource uses a Source per goroutine, like each VU owns the Source of its generators.
+func BenchmarkSource(b *testing.B) {
+	for _, bm := range benchmarkOps {
+		b.Run(bm.name, func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				s := NewRandomSource()
+				for pb.Next() {
+					bm.op(s)
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkLockedSource uses a single Source that is shared by all goroutines and protected by a mutex, which
+// serves as baseline for BenchmarkSource.
+func BenchmarkLockedSource(b *testing.B) {
+	for _, bm := range benchmarkOps {
+		b.Run(bm.name, func(b *testing.B) {
+			var mu sync.Mutex
+			s := NewRandomSource()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					mu.Lock()
+					bm.op(s)
+					mu.Unlock()
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package tracegen
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/grafana/xk6-client-tracing/pkg/random"
+)
+
+type DistributionType string
+
+const (
+	DistributionUniform     DistributionType = "uniform"
+	DistributionFixed       DistributionType = "fixed"
+	DistributionNormal      DistributionType = "normal"
+	DistributionExponential DistributionType = "exponential"
+	DistributionLogNormal   DistributionType = "lognormal"
+	DistributionPercentiles DistributionType = "percentiles"
+
+	// z99 is the 99th percentile of the standard normal distribution.
+	z99 = 2.3263478740408408
+)
+
+// DurationDistribution describes how span durations are distributed. All values are in milliseconds.
+type DurationDistribution struct {
+	// Type the type of the distribution (default: uniform)
+	Type DistributionType `js:"type"`
+	// Min and Max are the interval [Min, Max) of uniform durations. For other distributions they are optional bounds
+	// to which generated durations are clamped, a Max of 0 means no upper bound.
+	Min int64 `js:"min"`
+	Max int64 `js:"max"`
+	// Value the duration of fixed durations
+	Value float64 `js:"value"`
+	// Mean the mean of normal and exponential durations
+	Mean float64 `js:"mean"`
+	// StdDev the standard deviation of normal durations
+	StdDev float64 `js:"stddev"`
+	// Median the median of lognormal durations
+	Median float64 `js:"median"`
+	// P99 the 99th percentile of lognormal durations, which defines how long the tail of the distribution is
+	P99 float64 `js:"p99"`
+	// Percentiles maps percentiles to durations, e.g. {"50": 20, "99": 400, "100": 2000}. Durations between two
+	// percentiles are interpolated linearly, durations below the lowest percentile are interpolated from Min.
+	Percentiles map[string]float64 `js:"percentiles"`
+}
+
+// Range represents and interval with the given upper and lower bound [Max, Min)
+//
+// Deprecated: use DurationDistribution, which creates uniform durations if only Min and Max are set.
+type Range = DurationDistribution
+
+// durationSampler creates random durations of a distribution.
+type durationSampler func(rnd *random.Source) time.Duration
+
+// newDurationSampler validates the distribution and returns a function that samples durations from it.
+func newDurationSampler(d *DurationDistribution) (durationSampler, error) {
+	if d.Min < 0 || d.Max < 0 {
+		return nil, errors.New("duration bounds must not be negative")
+	}
+	if d.Max > 0 && d.Max < d.Min {
+		return nil, fmt.Errorf("duration max %d must not be smaller than min %d", d.Max, d.Min)
+	}
+
+	var sample func(rnd *random.Source) float64
+	switch d.Type {
+	case "", DistributionUniform:
+		if d.Max <= d.Min {
+			return nil, fmt.Errorf("uniform duration max %d must be greater than min %d", d.Max, d.Min)
+		}
+		return func(rnd *random.Source) time.Duration {
+			return rnd.Duration(time.Duration(d.Min)*time.Millisecond, time.Duration(d.Max)*time.Millisecond)
+		}, nil
+	case DistributionFixed:
+		if d.Value < 0 {
+			return nil, errors.New("fixed duration must not be negative")
+		}
+		sample = func(*random.Source) float64 { return d.Value }
+	case DistributionNormal:
+		if d.Mean <= 0 || d.StdDev < 0 {
+			return nil, errors.New("normal durations require a positive mean and a non-negative stddev")
+		}
+		sample = func(rnd *random.Source) float64 { return d.Mean + d.StdDev*rnd.NormFloat64() }
+	case DistributionExponential:
+		if d.Mean <= 0 {
+			return nil, errors.New("exponential durations require a positive mean")
+		}
+		sample = func(rnd *random.Source) float64 { return d.Mean * rnd.ExpFloat64() }
+	case DistributionLogNormal:
+		if d.Median <= 0 || d.P99 <= d.Median {
+			return nil, errors.New("lognormal durations require a positive median and a p99 greater than the median")
+		}
+		mu := math.Log(d.Median)
+		sigma := (math.Log(d.P99) - mu) / z99
+		sample = func(rnd *random.Source) float64 { return math.Exp(mu + sigma*rnd.NormFloat64()) }
+	case DistributionPercentiles:
+		table, err := newPercentileTable(d.Percentiles, float64(d.Min))
+		if err != nil {
+			return nil, err
+		}
+		sample = func(rnd *random.Source) float64 { return table.value(100 * rnd.Float64()) }
+	default:
+		return nil, fmt.Errorf("unknown duration distribution %q", d.Type)
+	}
+
+	return func(rnd *random.Source) time.Duration {
+		ms := max(sample(rnd), float64(d.Min))
+		if d.Max > 0 {
+			ms = min(ms, float64(d.Max))
+		}
+		return time.Duration(ms * float64(time.Millisecond))
+	}, nil
+}
+
+// percentileTable contains the points of a piecewise linear quantile function.
+type percentileTable struct {
+	percentiles []float64
+	values      []float64
+}
+
+func newPercentileTable(percentiles map[string]float64, minValue float64) (*percentileTable, error) {
+	if len(percentiles) == 0 {
+		return nil, errors.New("percentile durations require at least one percentile")
+	}
+
+	type point struct{ percentile, value float64 }
+	points := make([]point, 0, len(percentiles))
+	for k, v := range percentiles {
+		p, err := strconv.ParseFloat(k, 64)
+		if err != nil || p < 0 || p > 100 {
+			return nil, fmt.Errorf("invalid percentile %q, percentiles must be numbers between 0 and 100", k)
+		}
+		points = append(points, point{percentile: p, value: v})
+	}
+	slices.SortFunc(points, func(a, b point) int { return cmp.Compare(a.percentile, b.percentile) })
+
+	table := &percentileTable{}
+	for i, p := range points {
+		if i > 0 && (p.percentile == points[i-1].percentile || p.value < points[i-1].value) {
+			return nil, errors.New("percentiles must be unique and their durations must increase with the percentile")
+		}
+		table.percentiles = append(table.percentiles, p.percentile)
+		table.values = append(table.values, p.value)
+	}
+	if table.values[0] < 0 {
+		return nil, errors.New("durations of percentiles must not be negative")
+	}
+
+	if table.percentiles[0] > 0 {
+		table.percentiles = slices.Insert(table.percentiles, 0, 0)
+		table.values = slices.Insert(table.values, 0, min(minValue, table.values[0]))
+	}
+	return table, nil
+}
+
+// value returns the interpolated duration at percentile p. Percentiles above the highest percentile of the table
+// have the duration of the highest percentile.
+func (t *percentileTable) value(p float64) float64 {
+	i, found := slices.BinarySearch(t.percentiles, p)
+	switch {
+	case found:
+		return t.values[i]
+	case i == len(t.percentiles):
+		return t.values[i-1]
+	}
+	fraction := (p - t.percentiles[i-1]) / (t.percentiles[i] - t.percentiles[i-1])
+	return t.values[i-1] + fraction*(t.values[i]-t.values[i-1])
+}
@@ -0,0 +1,151 @@
+package tracegen
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/xk6-client-tracing/pkg/random"
+)
+
+func TestDurationSampler(t *testing.T) {
+	tests := map[string]struct {
+		distribution DurationDistribution
+		median       time.Duration
+		p99          time.Duration
+		min, max     time.Duration
+	}{
+		"uniform": {
+			distribution: DurationDistribution{Min: 100, Max: 200},
+			median:       150 * time.Millisecond,
+			p99:          199 * time.Millisecond,
+			min:          100 * time.Millisecond,
+			max:          200 * time.Millisecond,
+		},
+		"fixed": {
+			distribution: DurationDistribution{Type: DistributionFixed, Value: 42.5},
+			median:       42500 * time.Microsecond,
+			p99:          42500 * time.Microsecond,
+			min:          42500 * time.Microsecond,
+			max:          42500 * time.Microsecond,
+		},
+		"normal": {
+			distribution: DurationDistribution{Type: DistributionNormal, Mean: 100, StdDev: 10},
+			median:       100 * time.Millisecond,
+			p99:          123 * time.Millisecond,
+			min:          50 * time.Millisecond,
+			max:          150 * time.Millisecond,
+		},
+		"exponential": {
+			distribution: DurationDistribution{Type: DistributionExponential, Mean: 100, Max: 300},
+			median:       69 * time.Millisecond,
+			p99:          300 * time.Millisecond,
+			min:          0,
+			max:          300 * time.Millisecond,
+		},
+		"lognormal": {
+			distribution: DurationDistribution{Type: DistributionLogNormal, Median: 20, P99: 500},
+			median:       20 * time.Millisecond,
+			p99:          500 * time.Millisecond,
+			min:          0,
+			max:          time.Hour,
+		},
+		"percentiles": {
+			distribution: DurationDistribution{Type: DistributionPercentiles, Min: 10, Percentiles: map[string]float64{
+				"50": 20, "99": 400, "100": 1000,
+			}},
+			median: 20 * time.Millisecond,
+			p99:    400 * time.Millisecond,
+			min:    10 * time.Millisecond,
+			max:    time.Second,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			sample, err := newDurationSampler(&tt.distribution)
+			require.NoError(t, err)
+
+			rnd := random.NewSource(1)
+			durations := make([]time.Duration, 20000)
+			for i := range durations {
+				durations[i] = sample(rnd)
+			}
+			slices.Sort(durations)
+
+			assert.GreaterOrEqual(t, durations[0], tt.min)
+			assert.LessOrEqual(t, durations[len(durations)-1], tt.max)
+			assert.InEpsilon(t, float64(tt.median), float64(durations[len(durations)/2]), 0.05)
+			assert.InEpsilon(t, float64(tt.p99), float64(durations[len(durations)*99/100]), 0.1)
+		})
+	}
+}
+
+func TestDurationSamplerInvalid(t *testing.T) {
+	for name, d := range map[string]DurationDistribution{
+		"uniform without interval": {Min: 100},
+		"negative bound":           {Type: DistributionFixed, Min: -1},
+		"max below min":            {Type: DistributionFixed, Min: 10, Max: 5},
+		"unknown type":             {Type: "pareto"},
+		"normal without mean":      {Type: DistributionNormal, StdDev: 1},
+		"exponential without mean": {Type: DistributionExponential},
+		"lognormal p99 below":      {Type: DistributionLogNormal, Median: 20, P99: 10},
+		"no percentiles":           {Type: DistributionPercentiles},
+		"percentile out of range":  {Type: DistributionPercentiles, Percentiles: map[string]float64{"101": 1}},
+		"percentile not a number":  {Type: DistributionPercentiles, Percentiles: map[string]float64{"p50": 1}},
+		"duplicate percentile":     {Type: DistributionPercentiles, Percentiles: map[string]float64{"50": 1, "50.0": 2}},
+		"decreasing durations":     {Type: DistributionPercentiles, Percentiles: map[string]float64{"50": 10, "90": 5}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := newDurationSampler(&d)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestTemplatedGenerator_DurationDistribution(t *testing.T) {
+	template := TraceTemplate{
+		Spans: []SpanTemplate{
+			{Service: "shop-backend", Duration: &DurationDistribution{Type: DistributionFixed, Value: 250}},
+			{Service: "shop-backend", Duration: &DurationDistribution{Type: DistributionLogNormal, Median: 20, P99: 200, Max: 100}},
+		},
+	}
+	gen, err := NewTemplatedGenerator(&template, random.NewSource(1))
+	require.NoError(t, err)
+
+	for range 100 {
+		spans := gen.Traces().ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+		require.Equal(t, 2, spans.Len())
+		root, child := spans.At(0), spans.At(1)
+		assert.Equal(t, 250*time.Millisecond, root.EndTimestamp().AsTime().Sub(root.StartTimestamp().AsTime()))
+		assert.LessOrEqual(t, child.EndTimestamp().AsTime().Sub(child.StartTimestamp().AsTime()), 100*time.Millisecond)
+	}
+
+	template.Spans[1].Duration = &DurationDistribution{Type: DistributionLogNormal, Median: 20}
+	_, err = NewTemplatedGenerator(&template, nil)
+	assert.ErrorContains(t, err, "invalid duration of span 1")
+}
+
+func TestTemplatedGenerator_ZeroDuration(t *testing.T) {
+	semantics := SemanticsMessaging
+	template := TraceTemplate{
+		Spans: []SpanTemplate{
+			{Service: "shop-backend", Name: ptr("checkout"), Duration: &DurationDistribution{Type: DistributionFixed, Value: 0}},
+			{Service: "shop-backend", Name: ptr("publish-orders"), AttributeSemantics: &semantics},
+			{Service: "order-worker", Name: ptr("process-orders"), AttributeSemantics: &semantics},
+			{Service: "shop-backend", Name: ptr("reserve"), ParentIDX: ptr(0), Timing: TimingSequential},
+		},
+	}
+	gen, err := NewTemplatedGenerator(&template, random.NewSource(1))
+	require.NoError(t, err)
+
+	// children of a parent without duration have no duration either
+	for range testRounds {
+		for _, span := range iterSpans(gen.Traces()) {
+			assert.Equal(t, span.StartTimestamp(), span.EndTimestamp(), span.Name())
+		}
+	}
+}
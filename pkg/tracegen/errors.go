@@ -0,0 +1,113 @@
+package tracegen
+
+import (
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"google.golang.org/grpc/codes"
+)
+
+// spanFailure describes whether and why a span of a generated trace fails.
+type spanFailure int
+
+const (
+	failureNone spanFailure = iota
+	// failureOwn the span fails because of its error rate
+	failureOwn
+	// failurePropagated the span fails because one of its children failed
+	failurePropagated
+	// failureCallee the client span fails because the server span it calls failed
+	failureCallee
+)
+
+// failures decides which spans of the next trace fail. Children are created after their parents, so the instances
+// are visited in reverse order, which allows failures to propagate up the parent chain.
+func (g *TemplatedGenerator) failures(instances []*spanInstance) {
+	for i := len(instances) - 1; i >= 0; i-- {
+		inst := instances[i]
+		tmpl := inst.tmpl
+		if inst.failure == failureNone && tmpl.errorRate > 0 && g.rnd.Float32() < tmpl.errorRate {
+			inst.failure = failureOwn
+			// a failed call of an instrumented server fails on the server side
+			if inst.callee != nil && inst.callee.failure == failureNone {
+				inst.callee.failure = failureOwn
+				inst.failure = failureCallee
+			}
+		}
+
+		// messages are processed asynchronously, so consumers don't affect their producers
+		parent := inst.parent
+		if inst.failure == failureNone || parent == nil || parent.failure != failureNone || tmpl.kind == ptrace.SpanKindConsumer {
+			continue
+		}
+		if parent.callee == inst {
+			parent.failure = failureCallee
+		} else if parent.tmpl.errorPropagation > 0 && g.rnd.Float32() < parent.tmpl.errorPropagation {
+			parent.failure = failurePropagated
+		}
+	}
+}
+
+// generateErrorAttributes adds the error status code of the semantic convention of the span. The status of the
+// span is set by the semantic convention or setErrorStatus.
+func (g *TemplatedGenerator) generateErrorAttributes(tmpl *internalSpanTemplate, span *ptrace.Span) {
+	if tmpl.attributeSemantics == nil {
+		return
+	}
+
+	attrs := span.Attributes()
+	switch *tmpl.attributeSemantics {
+	case SemanticsHTTP:
+		// status codes of server spans are only errors for server errors
+		switch tmpl.kind {
+		case ptrace.SpanKindServer:
+			putIfNotExists(attrs, attrHTTPStatusCode, g.rnd.HTTPStatusServerErr())
+		case ptrace.SpanKindClient:
+			putIfNotExists(attrs, attrHTTPStatusCode, g.rnd.HTTPStatusErr())
+		}
+	case SemanticsRPC:
+		if tmpl.rpc != nil && !tmpl.rpc.callsServer {
+			putIfNotExists(attrs, attrRPCGRPCStatusCode, g.rnd.GRPCStatusErr())
+		}
+	case SemanticsDB:
+		if tmpl.db == nil {
+			return
+		}
+		if code := g.rnd.DBErrorCode(tmpl.db.system); code != "" {
+			putIfNotExists(attrs, attrDBResponseStatusCode, code)
+		} else {
+			putIfNotExists(attrs, attrErrorType, "timeout")
+		}
+	}
+}
+
+// setErrorStatus sets the error status if the semantic convention of the span didn't set it already.
+func (g *TemplatedGenerator) setErrorStatus(span *ptrace.Span) {
+	if span.Status().Code() == ptrace.StatusCodeError {
+		return
+	}
+
+	message := g.generateRandomExceptionMsg()
+	if st, found := getHTTPStatusCode(span.Attributes()); found && st >= 400 {
+		message = http.StatusText(int(st))
+	} else if st, found := span.Attributes().Get(attrRPCGRPCStatusCode); found && st.Int() != 0 {
+		message = codes.Code(st.Int()).String()
+	}
+	span.Status().SetCode(ptrace.StatusCodeError)
+	span.Status().SetMessage(message)
+}
+
+// probability returns the value of a template or the default, which must be between 0 and 1.
+func probability(name string, value, defaultValue *float32) (float32, error) {
+	if value == nil {
+		value = defaultValue
+	}
+	if value == nil {
+		return 0, nil
+	}
+	if *value < 0 || *value > 1 {
+		return 0, fmt.Errorf("%s must be between 0 and 1, but was %v", name, *value)
+	}
+	return *value, nil
+}
@@ -0,0 +1,156 @@
+package tracegen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/grafana/xk6-client-tracing/pkg/random"
+)
+
+// errorTestTemplate returns a frontend server that calls a backend via HTTP, the backend queries a database.
+func errorTestTemplate() TraceTemplate {
+	http, db := SemanticsHTTP, SemanticsDB
+	return TraceTemplate{
+		Defaults: SpanDefaults{AttributeSemantics: &http},
+		Spans: []SpanTemplate{
+			{Service: "frontend", Name: ptr("GET /checkout")},
+			{Service: "frontend", Name: ptr("POST /payment")},
+			{Service: "backend", Name: ptr("POST /payment")},
+			{Service: "backend", Name: ptr("query payments")},
+			{Service: "postgres", Name: ptr("SELECT payments"), AttributeSemantics: &db},
+		},
+	}
+}
+
+func TestTemplatedGenerator_ErrorRate(t *testing.T) {
+	template := errorTestTemplate()
+	template.Spans[2].ErrorRate = ptr(float32(1))
+	gen, err := NewTemplatedGenerator(&template, random.NewSource(1))
+	require.NoError(t, err)
+
+	for range 20 {
+		spans := collectSpansByName(gen.Traces())
+		server, client := spans["backend/POST /payment"], spans["frontend/POST /payment"]
+		require.Equal(t, ptrace.SpanKindServer, server.Kind())
+		require.Equal(t, ptrace.SpanKindClient, client.Kind())
+
+		assert.Equal(t, ptrace.StatusCodeError, server.Status().Code())
+		status, _ := getHTTPStatusCode(server.Attributes())
+		assert.GreaterOrEqual(t, status, int64(500))
+		// the client receives the status of the server
+		assert.Equal(t, ptrace.StatusCodeError, client.Status().Code())
+		clientStatus, _ := getHTTPStatusCode(client.Attributes())
+		assert.Equal(t, status, clientStatus)
+
+		// failures are not propagated by default
+		assert.NotEqual(t, ptrace.StatusCodeError, spans["frontend/GET /checkout"].Status().Code())
+		assert.NotEqual(t, ptrace.StatusCodeError, spans["postgres/SELECT payments"].Status().Code())
+	}
+}
+
+func TestTemplatedGenerator_ErrorPropagation(t *testing.T) {
+	template := errorTestTemplate()
+	template.Defaults.ErrorPropagation = ptr(float32(1))
+	template.Spans[4].ErrorRate = ptr(float32(1))
+	template.Spans[4].RandomEvents = &EventParams{ExceptionOnError: true}
+	gen, err := NewTemplatedGenerator(&template, random.NewSource(1))
+	require.NoError(t, err)
+
+	spans := collectSpansByName(gen.Traces())
+	for name, span := range spans {
+		assert.Equal(t, ptrace.StatusCodeError, span.Status().Code(), name)
+	}
+	db := spans["postgres/SELECT payments"]
+	code, found := db.Attributes().Get(attrDBResponseStatusCode)
+	assert.True(t, found)
+	assert.Equal(t, code.Str(), db.Status().Message())
+	assert.True(t, hasEvent(db, "exception"))
+	// propagated failures of HTTP servers are server errors
+	status, _ := getHTTPStatusCode(spans["frontend/GET /checkout"].Attributes())
+	assert.GreaterOrEqual(t, status, int64(500))
+
+	// the propagation of a span overrides the default
+	template = errorTestTemplate()
+	template.Defaults.ErrorPropagation = ptr(float32(1))
+	template.Spans[0].ErrorPropagation = ptr(float32(0))
+	template.Spans[4].ErrorRate = ptr(float32(1))
+	gen, err = NewTemplatedGenerator(&template, random.NewSource(1))
+	require.NoError(t, err)
+
+	spans = collectSpansByName(gen.Traces())
+	assert.Equal(t, ptrace.StatusCodeError, spans["frontend/POST /payment"].Status().Code())
+	assert.NotEqual(t, ptrace.StatusCodeError, spans["frontend/GET /checkout"].Status().Code())
+}
+
+func TestTemplatedGenerator_ErrorRateClient(t *testing.T) {
+	template := errorTestTemplate()
+	template.Spans[1].ErrorRate = ptr(float32(1))
+	gen, err := NewTemplatedGenerator(&template, random.NewSource(1))
+	require.NoError(t, err)
+
+	// failed calls of instrumented servers fail on the server side
+	spans := collectSpansByName(gen.Traces())
+	assert.Equal(t, ptrace.StatusCodeError, spans["frontend/POST /payment"].Status().Code())
+	assert.Equal(t, ptrace.StatusCodeError, spans["backend/POST /payment"].Status().Code())
+}
+
+func TestTemplatedGenerator_ErrorRateDistribution(t *testing.T) {
+	template := errorTestTemplate()
+	template.Defaults.ErrorRate = ptr(float32(0.2))
+	template.Spans[0].ErrorRate = ptr(float32(0))
+	// the failures of the client are transferred to the database, which would fail more often
+	template.Spans[3].ErrorRate = ptr(float32(0))
+	gen, err := NewTemplatedGenerator(&template, random.NewSource(1))
+	require.NoError(t, err)
+
+	var failed int
+	for range 1000 {
+		if collectSpansByName(gen.Traces())["postgres/SELECT payments"].Status().Code() == ptrace.StatusCodeError {
+			failed++
+		}
+	}
+	assert.InDelta(t, 200, failed, 50)
+	for range 100 {
+		assert.NotEqual(t, ptrace.StatusCodeError, collectSpansByName(gen.Traces())["frontend/GET /checkout"].Status().Code())
+	}
+}
+
+func TestTemplatedGenerator_ErrorRateInvalid(t *testing.T) {
+	template := errorTestTemplate()
+	template.Spans[1].ErrorRate = ptr(float32(1.5))
+	_, err := NewTemplatedGenerator(&template, nil)
+	assert.ErrorContains(t, err, "errorRate must be between 0 and 1")
+
+	template = errorTestTemplate()
+	template.Defaults.ErrorPropagation = ptr(float32(-1))
+	_, err = NewTemplatedGenerator(&template, nil)
+	assert.ErrorContains(t, err, "errorPropagation must be between 0 and 1")
+}
+
+// collectSpansByName returns the spans of a trace by service and span name.
+func collectSpansByName(traces ptrace.Traces) map[string]ptrace.Span {
+	spans := map[string]ptrace.Span{}
+	for i := 0; i < traces.ResourceSpans().Len(); i++ {
+		rs := traces.ResourceSpans().At(i)
+		service, _ := rs.Resource().Attributes().Get(attrServiceName)
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			for k := 0; k < rs.ScopeSpans().At(j).Spans().Len(); k++ {
+				span := rs.ScopeSpans().At(j).Spans().At(k)
+				spans[service.Str()+"/"+span.Name()] = span
+			}
+		}
+	}
+	return spans
+}
+
+func hasEvent(span ptrace.Span, name string) bool {
+	for i := 0; i < span.Events().Len(); i++ {
+		if span.Events().At(i).Name() == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,390 @@
+package tracegen
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/grafana/xk6-client-tracing/pkg/random"
+)
+
+const (
+	FileFormatJSON  = "json"
+	FileFormatProto = "proto"
+
+	// ReplaySequential replays the traces in the order they appear in the files, each generator starts with
+	// the first trace.
+	ReplaySequential = "sequential"
+	// ReplayRandom replays randomly selected traces.
+	ReplayRandom = "random"
+	// ReplayRoundRobin replays the traces in order, the position is shared by all generators that use the same
+	// files. This way all VUs together replay each trace once before the first one is repeated.
+	ReplayRoundRobin = "roundrobin"
+
+	defaultReplayCount = 1
+)
+
+var corpora = struct {
+	sync.Mutex
+	m map[string]*traceCorpus
+}{m: make(map[string]*traceCorpus)}
+
+// FileParams configures a FileGenerator.
+type FileParams struct {
+	// Paths of the files containing OTLP traces. Gzip compressed files are decompressed.
+	Paths []string `js:"paths"`
+	// Format of the files: FileFormatJSON for OTLP JSON (one document or JSON lines) or FileFormatProto for
+	// OTLP protobuf (a single message or length-delimited messages). If empty, the format is derived from
+	// the file extension or content.
+	Format string `js:"format"`
+	// Mode that defines how the replayed traces are selected: ReplaySequential, ReplayRandom or
+	// ReplayRoundRobin (default: ReplaySequential)
+	Mode string `js:"mode"`
+	// Count of traces returned by each call of Traces (default: 1)
+	Count int `js:"count"`
+}
+
+// traceCorpus contains the loaded traces, each element contains the spans of a single trace.
+// The traces must not be modified, since they are shared by all generators using the same files.
+type traceCorpus struct {
+	traces []ptrace.Traces
+	next   atomic.Uint64
+}
+
+// FileGenerator replays traces loaded from OTLP JSON or protobuf files. Each replayed trace gets new
+// trace and span IDs and its timestamps are shifted such that the trace ends now. The relative timing and
+// the parent/child relationships of the spans are preserved.
+type FileGenerator struct {
+	rnd    *random.Source
+	mode   string
+	count  int
+	corpus *traceCorpus
+	next   int
+}
+
+// NewFileGenerator creates a generator that replays the traces from the files in params. If rnd is nil, a
+// randomly seeded Source is used.
+func NewFileGenerator(params *FileParams, rnd *random.Source) (*FileGenerator, error) {
+	if len(params.Paths) == 0 {
+		return nil, errors.New("fail to create new file generator: no paths specified")
+	}
+	mode := params.Mode
+	if mode == "" {
+		mode = ReplaySequential
+	}
+	if mode != ReplaySequential && mode != ReplayRandom && mode != ReplayRoundRobin {
+		return nil, fmt.Errorf("fail to create new file generator: invalid mode %s", mode)
+	}
+	count := params.Count
+	if count <= 0 {
+		count = defaultReplayCount
+	}
+
+	corpus, err := loadCorpus(params.Paths, params.Format)
+	if err != nil {
+		return nil, fmt.Errorf("fail to create new file generator: %w", err)
+	}
+
+	if rnd == nil {
+		rnd = random.NewRandomSource()
+	}
+
+	return &FileGenerator{
+		rnd:    rnd,
+		mode:   mode,
+		count:  count,
+		corpus: corpus,
+	}, nil
+}
+
+func (g *FileGenerator) Traces() ptrace.Traces {
+	traces := ptrace.NewTraces()
+	now := time.Now()
+	for i := 0; i < g.count; i++ {
+		replayTrace(g.rnd, g.selectTrace(), traces, now)
+	}
+	return traces
+}
+
+func (g *FileGenerator) selectTrace() ptrace.Traces {
+	n := len(g.corpus.traces)
+	switch g.mode {
+	case ReplayRandom:
+		return g.corpus.traces[g.rnd.IntN(n)]
+	case ReplayRoundRobin:
+		return g.corpus.traces[(g.corpus.next.Add(1)-1)%uint64(n)]
+	default:
+		trace := g.corpus.traces[g.next]
+		g.next = (g.next + 1) % n
+		return trace
+	}
+}
+
+// replayTrace appends a copy of src with new IDs and shifted timestamps to dst.
+func replayTrace(rnd *random.Source, src ptrace.Traces, dst ptrace.Traces, now time.Time) {
+	var end pcommon.Timestamp
+	forEachSpan(src, func(span ptrace.Span) {
+		end = max(end, span.EndTimestamp())
+	})
+	shift := now.Sub(end.AsTime())
+
+	traceIDs := map[pcommon.TraceID]pcommon.TraceID{}
+	spanIDs := map[pcommon.SpanID]pcommon.SpanID{}
+	mapSpanID := func(id pcommon.SpanID) pcommon.SpanID {
+		if _, found := spanIDs[id]; !found {
+			spanIDs[id] = rnd.SpanID()
+		}
+		return spanIDs[id]
+	}
+	forEachSpan(src, func(span ptrace.Span) {
+		if _, found := traceIDs[span.TraceID()]; !found {
+			traceIDs[span.TraceID()] = rnd.TraceID()
+		}
+		mapSpanID(span.SpanID())
+	})
+
+	for i := 0; i < src.ResourceSpans().Len(); i++ {
+		rs := dst.ResourceSpans().AppendEmpty()
+		src.ResourceSpans().At(i).CopyTo(rs)
+
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			spans := rs.ScopeSpans().At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				span := spans.At(k)
+				span.SetTraceID(traceIDs[span.TraceID()])
+				span.SetSpanID(spanIDs[span.SpanID()])
+				if !span.ParentSpanID().IsEmpty() {
+					span.SetParentSpanID(mapSpanID(span.ParentSpanID()))
+				}
+				span.SetStartTimestamp(shiftTimestamp(span.StartTimestamp(), shift))
+				span.SetEndTimestamp(shiftTimestamp(span.EndTimestamp(), shift))
+				for l := 0; l < span.Events().Len(); l++ {
+					event := span.Events().At(l)
+					event.SetTimestamp(shiftTimestamp(event.Timestamp(), shift))
+				}
+				// links to spans of other traces in the file are kept as they are
+				for l := 0; l < span.Links().Len(); l++ {
+					link := span.Links().At(l)
+					if traceID, found := traceIDs[link.TraceID()]; found {
+						link.SetTraceID(traceID)
+						link.SetSpanID(mapSpanID(link.SpanID()))
+					}
+				}
+			}
+		}
+	}
+}
+
+func shiftTimestamp(ts pcommon.Timestamp, shift time.Duration) pcommon.Timestamp {
+	if ts == 0 {
+		return ts
+	}
+	return pcommon.NewTimestampFromTime(ts.AsTime().Add(shift))
+}
+
+// loadCorpus loads the traces from the given files. Files are only loaded once, generators using the same
+// files share the loaded traces.
+func loadCorpus(paths []string, format string) (*traceCorpus, error) {
+	key := format + "\x00" + strings.Join(paths, "\x00")
+
+	corpora.Lock()
+	defer corpora.Unlock()
+
+	if corpus, found := corpora.m[key]; found {
+		return corpus, nil
+	}
+
+	corpus := &traceCorpus{}
+	for _, path := range paths {
+		records, err := readTraceFile(path, format)
+		if err != nil {
+			return nil, err
+		}
+		for _, td := range records {
+			corpus.traces = append(corpus.traces, splitTraces(td)...)
+		}
+	}
+	if len(corpus.traces) == 0 {
+		return nil, errors.New("the files don't contain any spans")
+	}
+
+	corpora.m[key] = corpus
+	return corpus, nil
+}
+
+func readTraceFile(path, format string) ([]ptrace.Traces, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		data, err = io.ReadAll(gz)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress %s: %w", path, err)
+		}
+	}
+
+	if format == "" {
+		format = detectFileFormat(path, data)
+	}
+
+	var records []ptrace.Traces
+	switch format {
+	case FileFormatJSON:
+		records, err = decodeJSONTraces(data)
+	case FileFormatProto:
+		records, err = decodeProtoTraces(data)
+	default:
+		return nil, fmt.Errorf("invalid format: %s", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return records, nil
+}
+
+func detectFileFormat(path string, data []byte) string {
+	switch filepath.Ext(strings.TrimSuffix(path, ".gz")) {
+	case ".json", ".jsonl", ".ndjson":
+		return FileFormatJSON
+	case ".pb", ".binpb", ".proto", ".protobuf":
+		return FileFormatProto
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		return FileFormatJSON
+	}
+	return FileFormatProto
+}
+
+// decodeJSONTraces decodes a stream of OTLP JSON documents, which can be a single document or JSON lines.
+func decodeJSONTraces(data []byte) ([]ptrace.Traces, error) {
+	var records []ptrace.Traces
+	dec := json.NewDecoder(bufio.NewReader(bytes.NewReader(data)))
+	for {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		td, err := (&ptrace.JSONUnmarshaler{}).UnmarshalTraces(raw)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, td)
+	}
+}
+
+// decodeProtoTraces decodes a single OTLP protobuf message or messages that are prefixed with their size
+// as 4 byte big endian integer. A serialized TracesData message always starts with the tag of the
+// resource_spans field (0x0a), while the size prefix would have to exceed 160MiB to start with that byte.
+func decodeProtoTraces(data []byte) ([]ptrace.Traces, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	if data[0] == 0x0a {
+		td, err := (&ptrace.ProtoUnmarshaler{}).UnmarshalTraces(data)
+		if err != nil {
+			return nil, err
+		}
+		return []ptrace.Traces{td}, nil
+	}
+
+	var records []ptrace.Traces
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, errors.New("truncated size prefix")
+		}
+		size := binary.BigEndian.Uint32(data)
+		if uint64(size) > uint64(len(data)-4) {
+			return nil, errors.New("truncated message")
+		}
+
+		td, err := (&ptrace.ProtoUnmarshaler{}).UnmarshalTraces(data[4 : 4+size])
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, td)
+		data = data[4+size:]
+	}
+	return records, nil
+}
+
+// splitTraces splits the spans by trace ID, resources and scopes are copied to each of the resulting traces.
+func splitTraces(td ptrace.Traces) []ptrace.Traces {
+	type scopeKey struct {
+		traceID         pcommon.TraceID
+		resource, scope int
+	}
+
+	var (
+		traces    []ptrace.Traces
+		byTraceID = map[pcommon.TraceID]ptrace.Traces{}
+		scopes    = map[scopeKey]ptrace.ScopeSpans{}
+	)
+
+	for i := 0; i < td.ResourceSpans().Len(); i++ {
+		rs := td.ResourceSpans().At(i)
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			ss := rs.ScopeSpans().At(j)
+			for k := 0; k < ss.Spans().Len(); k++ {
+				span := ss.Spans().At(k)
+
+				trace, found := byTraceID[span.TraceID()]
+				if !found {
+					trace = ptrace.NewTraces()
+					byTraceID[span.TraceID()] = trace
+					traces = append(traces, trace)
+				}
+
+				key := scopeKey{traceID: span.TraceID(), resource: i, scope: j}
+				scope, found := scopes[key]
+				if !found {
+					newRS := trace.ResourceSpans().AppendEmpty()
+					rs.Resource().CopyTo(newRS.Resource())
+					newRS.SetSchemaUrl(rs.SchemaUrl())
+					scope = newRS.ScopeSpans().AppendEmpty()
+					ss.Scope().CopyTo(scope.Scope())
+					scope.SetSchemaUrl(ss.SchemaUrl())
+					scopes[key] = scope
+				}
+				span.CopyTo(scope.Spans().AppendEmpty())
+			}
+		}
+	}
+
+	return traces
+}
+
+func forEachSpan(td ptrace.Traces, f func(span ptrace.Span)) {
+	for i := 0; i < td.ResourceSpans().Len(); i++ {
+		rs := td.ResourceSpans().At(i)
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			spans := rs.ScopeSpans().At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				f(spans.At(k))
+			}
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package tracegen
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestFileGenerator_Traces(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	writeTestFile(t, path, FileFormatJSON, false, testFileTraces("first"), testFileTraces("second"))
+
+	gen, err := NewFileGenerator(&FileParams{Paths: []string{path}}, nil)
+	require.NoError(t, err)
+	require.Len(t, gen.corpus.traces, 4)
+
+	for round := 0; round < testRounds; round++ {
+		original := gen.corpus.traces[round%4]
+		rootName := []string{"root", "first", "root", "second"}[round%4]
+		originalRoot, originalChild := spanByName(t, original, rootName), spanByName(t, original, "child")
+
+		before := time.Now()
+		traces := gen.Traces()
+
+		require.Equal(t, 2, traces.SpanCount())
+		assert.Equal(t, 2, traces.ResourceSpans().Len())
+
+		root, child := spanByName(t, traces, rootName), spanByName(t, traces, "child")
+		assert.NotEqual(t, originalRoot.TraceID(), root.TraceID())
+		assert.NotEqual(t, originalRoot.SpanID(), root.SpanID())
+		assert.Equal(t, root.TraceID(), child.TraceID())
+		assert.Equal(t, root.SpanID(), child.ParentSpanID())
+		assert.True(t, root.ParentSpanID().IsEmpty())
+
+		// the trace ends now and keeps its relative timing
+		assert.False(t, root.EndTimestamp().AsTime().Before(before))
+		assert.Equal(t, originalRoot.EndTimestamp()-originalRoot.StartTimestamp(), root.EndTimestamp()-root.StartTimestamp())
+		assert.Equal(t, originalChild.StartTimestamp()-originalRoot.StartTimestamp(), child.StartTimestamp()-root.StartTimestamp())
+		assert.Equal(t, child.StartTimestamp(), child.Events().At(0).Timestamp())
+
+		// links within the trace point to the new span
+		require.Equal(t, 1, child.Links().Len())
+		assert.Equal(t, root.TraceID(), child.Links().At(0).TraceID())
+		assert.Equal(t, root.SpanID(), child.Links().At(0).SpanID())
+	}
+}
+
+func TestFileGenerator_ProtoGzip(t *testing.T) {
+	dir := t.TempDir()
+	delimited := filepath.Join(dir, "traces.binpb.gz")
+	writeTestFile(t, delimited, FileFormatProto, true, testFileTraces("a"), testFileTraces("b"))
+	single := filepath.Join(dir, "single.pb")
+	writeTestFile(t, single, "", false, testFileTraces("c"))
+
+	gen, err := NewFileGenerator(&FileParams{Paths: []string{delimited, single}, Count: 3}, nil)
+	require.NoError(t, err)
+	require.Len(t, gen.corpus.traces, 6)
+
+	traces := gen.Traces()
+	assert.Equal(t, 6, traces.SpanCount())
+	assert.Equal(t, 3, countTraces(traces))
+}
+
+func TestFileGenerator_Modes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.json")
+	writeTestFile(t, path, FileFormatJSON, false, testFileTraces("a"), testFileTraces("b"), testFileTraces("c"))
+
+	t.Run("round robin", func(t *testing.T) {
+		gen1, err := NewFileGenerator(&FileParams{Paths: []string{path}, Mode: ReplayRoundRobin}, nil)
+		require.NoError(t, err)
+		gen2, err := NewFileGenerator(&FileParams{Paths: []string{path}, Mode: ReplayRoundRobin}, nil)
+		require.NoError(t, err)
+		assert.Same(t, gen1.corpus, gen2.corpus)
+
+		// both generators share the position
+		first := gen1.selectTrace()
+		second := gen2.selectTrace()
+		assert.NotEqual(t, firstSpanName(first), firstSpanName(second))
+	})
+
+	t.Run("random", func(t *testing.T) {
+		gen, err := NewFileGenerator(&FileParams{Paths: []string{path}, Mode: ReplayRandom}, nil)
+		require.NoError(t, err)
+		for i := 0; i < testRounds; i++ {
+			assert.Equal(t, 2, gen.Traces().SpanCount())
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewFileGenerator(&FileParams{Paths: []string{path}, Mode: "shuffle"}, nil)
+		assert.Error(t, err)
+		_, err = NewFileGenerator(&FileParams{}, nil)
+		assert.Error(t, err)
+	})
+}
+
+func writeTestFile(t *testing.T, path, format string, compress bool, records ...ptrace.Traces) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	for _, td := range records {
+		switch format {
+		case FileFormatJSON:
+			data, err := (&ptrace.JSONMarshaler{}).MarshalTraces(td)
+			require.NoError(t, err)
+			buf.Write(data)
+			buf.WriteByte('\n')
+		case FileFormatProto:
+			data, err := (&ptrace.ProtoMarshaler{}).MarshalTraces(td)
+			require.NoError(t, err)
+			require.NoError(t, binary.Write(&buf, binary.BigEndian, uint32(len(data))))
+			buf.Write(data)
+		default:
+			data, err := (&ptrace.ProtoMarshaler{}).MarshalTraces(td)
+			require.NoError(t, err)
+			buf.Write(data)
+		}
+	}
+
+	data := buf.Bytes()
+	if compress {
+		var gzBuf bytes.Buffer
+		gz := gzip.NewWriter(&gzBuf)
+		_, err := gz.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, gz.Close())
+		data = gzBuf.Bytes()
+	}
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
+
+// testFileTraces creates a record that contains two traces with two spans each. The spans of each trace
+// belong to different services.
+func testFileTraces(secondName string) ptrace.Traces {
+	td := ptrace.NewTraces()
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	frontend := td.ResourceSpans().AppendEmpty()
+	frontend.Resource().Attributes().PutStr(attrServiceName, "frontend")
+	frontendSpans := frontend.ScopeSpans().AppendEmpty().Spans()
+	backend := td.ResourceSpans().AppendEmpty()
+	backend.Resource().Attributes().PutStr(attrServiceName, "backend")
+	backendSpans := backend.ScopeSpans().AppendEmpty().Spans()
+
+	for i, name := range []string{"root", secondName} {
+		traceID := pcommon.TraceID{byte(i + 1)}
+		root := frontendSpans.AppendEmpty()
+		root.SetTraceID(traceID)
+		root.SetSpanID(pcommon.SpanID{byte(i + 1), 1})
+		root.SetName(name)
+		root.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+		root.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(time.Second)))
+
+		child := backendSpans.AppendEmpty()
+		child.SetTraceID(traceID)
+		child.SetSpanID(pcommon.SpanID{byte(i + 1), 2})
+		child.SetParentSpanID(root.SpanID())
+		child.SetName("child")
+		child.SetStartTimestamp(pcommon.NewTimestampFromTime(start.Add(100 * time.Millisecond)))
+		child.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(900 * time.Millisecond)))
+		child.Events().AppendEmpty().SetTimestamp(child.StartTimestamp())
+		link := child.Links().AppendEmpty()
+		link.SetTraceID(traceID)
+		link.SetSpanID(root.SpanID())
+	}
+	return td
+}
+
+func spanByName(t *testing.T, traces ptrace.Traces, name string) ptrace.Span {
+	t.Helper()
+
+	var found *ptrace.Span
+	forEachSpan(traces, func(span ptrace.Span) {
+		if span.Name() == name && found == nil {
+			found = &span
+		}
+	})
+	require.NotNil(t, found, "span %s not found", name)
+	return *found
+}
+
+func firstSpanName(traces ptrace.Traces) string {
+	return traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Name()
+}
+
+func countTraces(traces ptrace.Traces) int {
+	ids := map[pcommon.TraceID]struct{}{}
+	forEachSpan(traces, func(span ptrace.Span) {
+		ids[span.TraceID()] = struct{}{}
+	})
+	return len(ids)
+}
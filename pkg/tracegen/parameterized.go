@@ -50,18 +50,24 @@ func (tp *TraceParams) setDefaults() {
 	}
 }
 
-func NewParameterizedGenerator(traceParams []*TraceParams) *ParameterizedGenerator {
+// NewParameterizedGenerator creates a new trace generator. If rnd is nil, a randomly seeded Source is used.
+func NewParameterizedGenerator(traceParams []*TraceParams, rnd *random.Source) *ParameterizedGenerator {
 	for _, tp := range traceParams {
 		tp.setDefaults()
 	}
+	if rnd == nil {
+		rnd = random.NewRandomSource()
+	}
 
 	return &ParameterizedGenerator{
 		traceParams: traceParams,
+		rnd:         rnd,
 	}
 }
 
 type ParameterizedGenerator struct {
 	traceParams []*TraceParams
+	rnd         *random.Source
 }
 
 func (g *ParameterizedGenerator) Traces() ptrace.Traces {
@@ -72,9 +78,9 @@ func (g *ParameterizedGenerator) Traces() ptrace.Traces {
 
 	for _, te := range g.traceParams {
 		rspan := resourceSpans.AppendEmpty()
-		serviceName := random.Service()
+		serviceName := g.rnd.Service()
 		if te.RandomServiceName {
-			serviceName += "." + random.String(5)
+			serviceName += "." + g.rnd.String(5)
 		}
 		resourceAttributes := g.constructAttributes(te.ResourceSize)
 		resourceAttributes.CopyTo(rspan.Resource().Attributes())
@@ -84,13 +90,13 @@ func (g *ParameterizedGenerator) Traces() ptrace.Traces {
 		ilss := rspan.ScopeSpans()
 		ilss.EnsureCapacity(1)
 		ils := ilss.AppendEmpty()
-		ils.Scope().SetName("k6-scope-name/" + random.String(15))
-		ils.Scope().SetVersion("k6-scope-version:v" + strconv.Itoa(random.IntBetween(0, 99)) + "." + strconv.Itoa(random.IntBetween(0, 99)))
+		ils.Scope().SetName("k6-scope-name/" + g.rnd.String(15))
+		ils.Scope().SetVersion("k6-scope-version:v" + strconv.Itoa(g.rnd.IntBetween(0, 99)) + "." + strconv.Itoa(g.rnd.IntBetween(0, 99)))
 
 		for range te.Count {
 			// Randomize traceID every time if we're generating multiple traces
 			if te.ID == "" || te.Count > 1 {
-				te.ID = random.TraceID().String()
+				te.ID = g.rnd.TraceID().String()
 				te.ParentID = ""
 			}
 
@@ -114,15 +120,15 @@ func (g *ParameterizedGenerator) Traces() ptrace.Traces {
 
 func (g *ParameterizedGenerator) generateSpan(t *TraceParams, dest ptrace.Span) {
 	endTime := time.Now().Round(time.Second)
-	startTime := endTime.Add(-time.Duration(random.IntN(500)+10) * time.Millisecond)
+	startTime := endTime.Add(-time.Duration(g.rnd.IntN(500)+10) * time.Millisecond)
 
 	var traceID pcommon.TraceID
 	b, _ := hex.DecodeString(t.ID)
 	copy(traceID[:], b)
 
-	spanName := random.Operation()
+	spanName := g.rnd.Operation()
 	if t.Spans.RandomName {
-		spanName += "." + random.String(5)
+		spanName += "." + g.rnd.String(5)
 	}
 
 	span := ptrace.NewSpan()
@@ -133,7 +139,7 @@ func (g *ParameterizedGenerator) generateSpan(t *TraceParams, dest ptrace.Span)
 		copy(parentID[:], p)
 		span.SetParentSpanID(parentID)
 	}
-	span.SetSpanID(random.SpanID())
+	span.SetSpanID(g.rnd.SpanID())
 	span.SetName(spanName)
 	span.SetKind(ptrace.SpanKindClient)
 	span.SetStartTimestamp(pcommon.NewTimestampFromTime(startTime))
@@ -141,14 +147,14 @@ func (g *ParameterizedGenerator) generateSpan(t *TraceParams, dest ptrace.Span)
 	span.TraceState().FromRaw("ot=x:y")
 
 	event := span.Events().AppendEmpty()
-	event.SetName(random.K6String(12))
+	event.SetName(g.rnd.K6String(12))
 	event.SetTimestamp(pcommon.NewTimestampFromTime(startTime))
-	event.Attributes().PutStr(random.K6String(5), random.K6String(12))
+	event.Attributes().PutStr(g.rnd.K6String(5), g.rnd.K6String(12))
 
 	link := span.Links().AppendEmpty()
 	link.SetTraceID(traceID)
-	link.SetSpanID(random.SpanID())
-	link.Attributes().PutStr(random.K6String(12), random.K6String(12))
+	link.SetSpanID(g.rnd.SpanID())
+	link.Attributes().PutStr(g.rnd.K6String(12), g.rnd.K6String(12))
 
 	status := span.Status()
 	status.SetCode(1)
@@ -181,8 +187,8 @@ func (g *ParameterizedGenerator) constructAttributes(size int) pcommon.Map {
 	// Fill the span with some random data
 	var currentSize int64
 	for currentSize < int64(size) {
-		rKey := random.K6String(random.IntN(15) + 1)
-		rVal := random.K6String(random.IntN(15) + 1)
+		rKey := g.rnd.K6String(g.rnd.IntN(15) + 1)
+		rVal := g.rnd.K6String(g.rnd.IntN(15) + 1)
 		attrs.PutStr(rKey, rVal)
 
 		currentSize += int64(unsafe.Sizeof(rKey)) + int64(unsafe.Sizeof(rVal))
@@ -27,7 +27,7 @@ func TestParameterizedGenerator_Traces(t *testing.T) {
 		},
 	}
 
-	generator := NewParameterizedGenerator(traceParams)
+	generator := NewParameterizedGenerator(traceParams, nil)
 	traces := generator.Traces()
 
 	// Basic validation
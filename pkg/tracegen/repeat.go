@@ -0,0 +1,97 @@
+package tracegen
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// Repeat defines how often a span and its children are repeated within the parent span, e.g. for N+1 queries or
+// a fan-out to multiple services.
+type Repeat struct {
+	// Min the minimum number of repetitions, can be 0
+	Min int `js:"min"`
+	// Max the maximum number of repetitions
+	Max int `js:"max"`
+}
+
+// spanInstance is a span of a single generated trace. A span template has zero or more instances for each instance
+// of its parent template, depending on the repeat and probability of the template.
+type spanInstance struct {
+	tmpl     *internalSpanTemplate
+	parent   *spanInstance
+	children []*spanInstance
+	// callee the server span called by a client span
+	callee  *spanInstance
+	failure spanFailure
+	// start and end are decided by schedule before the span is generated
+	start, end time.Time
+	span       ptrace.Span
+}
+
+// instances decides which spans the next trace consists of. Instances are returned in the order of the templates,
+// so parents always come before their children.
+func (g *TemplatedGenerator) instances() []*spanInstance {
+	instances := make([]*spanInstance, 0, len(g.spans))
+	byTemplate := make([][]*spanInstance, len(g.spans))
+	for _, tmpl := range g.spans {
+		parents := []*spanInstance{nil}
+		if tmpl.parent != nil {
+			parents = byTemplate[tmpl.parent.idx]
+		}
+
+		for _, parent := range parents {
+			count := g.repetitions(tmpl)
+			for range count {
+				inst := &spanInstance{tmpl: tmpl, parent: parent}
+				if parent != nil {
+					parent.children = append(parent.children, inst)
+					if parent.callee == nil && parent.tmpl.callee == tmpl {
+						parent.callee = inst
+					}
+				}
+				byTemplate[tmpl.idx] = append(byTemplate[tmpl.idx], inst)
+				instances = append(instances, inst)
+			}
+		}
+	}
+	return instances
+}
+
+// repetitions returns how often a span template is repeated within one instance of its parent.
+func (g *TemplatedGenerator) repetitions(tmpl *internalSpanTemplate) int {
+	if tmpl.probability < 1 && g.rnd.Float32() >= tmpl.probability {
+		return 0
+	}
+	if tmpl.repeatMin == tmpl.repeatMax {
+		return tmpl.repeatMin
+	}
+	return g.rnd.IntBetween(tmpl.repeatMin, tmpl.repeatMax+1)
+}
+
+// initializeRepeat validates the repeat and probability of a span template.
+func initializeRepeat(span *internalSpanTemplate, tmpl *SpanTemplate) error {
+	span.repeatMin, span.repeatMax, span.probability = 1, 1, 1
+	if tmpl.Repeat != nil {
+		if tmpl.Repeat.Min < 0 {
+			return errors.New("repeat min must not be negative")
+		}
+		if tmpl.Repeat.Max < max(tmpl.Repeat.Min, 1) {
+			return fmt.Errorf("repeat max %d must be at least 1 and not smaller than min %d", tmpl.Repeat.Max, tmpl.Repeat.Min)
+		}
+		span.repeatMin, span.repeatMax = tmpl.Repeat.Min, tmpl.Repeat.Max
+	}
+	if tmpl.Probability != nil {
+		p, err := probability("probability", tmpl.Probability, nil)
+		if err != nil {
+			return err
+		}
+		span.probability = p
+	}
+	if span.parent == nil && (span.repeatMin != 1 || span.repeatMax != 1 || span.probability != 1) {
+		return errors.New("the root span can't be repeated or optional")
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package tracegen
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/grafana/xk6-client-tracing/pkg/random"
+)
+
+func TestTemplatedGenerator_Repeat(t *testing.T) {
+	template := TraceTemplate{
+		Spans: []SpanTemplate{
+			{Service: "shop-backend", Name: ptr("list-articles")},
+			{Service: "shop-backend", Name: ptr("load-article"), Repeat: &Repeat{Min: 2, Max: 4}},
+			{Service: "postgres", Name: ptr("query-article")},
+			{Service: "shop-backend", Name: ptr("render"), ParentIDX: ptr(0)},
+		},
+	}
+	gen, err := NewTemplatedGenerator(&template, random.NewSource(1))
+	require.NoError(t, err)
+
+	counts := map[int]bool{}
+	for range 50 {
+		spans := spansByName(gen.Traces())
+		require.Len(t, spans["list-articles"], 1)
+		require.Len(t, spans["render"], 1)
+
+		articles := spans["load-article"]
+		require.GreaterOrEqual(t, len(articles), 2)
+		require.LessOrEqual(t, len(articles), 4)
+		counts[len(articles)] = true
+
+		// each repetition has its own children
+		require.Len(t, spans["query-article"], len(articles))
+		var parentIDs []pcommon.SpanID
+		for _, query := range spans["query-article"] {
+			parentIDs = append(parentIDs, query.ParentSpanID())
+		}
+		for _, article := range articles {
+			assert.Equal(t, spans["list-articles"][0].SpanID(), article.ParentSpanID())
+			assert.Contains(t, parentIDs, article.SpanID())
+		}
+	}
+	assert.Len(t, counts, 3)
+}
+
+func TestTemplatedGenerator_Probability(t *testing.T) {
+	template := TraceTemplate{
+		Spans: []SpanTemplate{
+			{Service: "shop-backend", Name: ptr("get-article")},
+			{Service: "shop-backend", Name: ptr("cache-miss"), Probability: ptr(float32(0.3))},
+			{Service: "postgres", Name: ptr("query-article")},
+			{Service: "shop-backend", Name: ptr("never"), ParentIDX: ptr(0), Probability: ptr(float32(0))},
+		},
+	}
+	gen, err := NewTemplatedGenerator(&template, random.NewSource(1))
+	require.NoError(t, err)
+
+	var misses int
+	for range 1000 {
+		spans := spansByName(gen.Traces())
+		require.Len(t, spans["get-article"], 1)
+		require.Empty(t, spans["never"])
+		// the children of missing spans are missing as well
+		require.Len(t, spans["query-article"], len(spans["cache-miss"]))
+		misses += len(spans["cache-miss"])
+	}
+	assert.InDelta(t, 300, misses, 50)
+}
+
+func TestTemplatedGenerator_SequentialTiming(t *testing.T) {
+	template := TraceTemplate{
+		Spans: []SpanTemplate{
+			{Service: "shop-backend", Name: ptr("list-articles")},
+			{Service: "shop-backend", Name: ptr("parallel"), Repeat: &Repeat{Min: 3, Max: 3}},
+			{Service: "shop-backend", Name: ptr("sequential"), ParentIDX: ptr(0), Repeat: &Repeat{Min: 3, Max: 5}, Timing: TimingSequential},
+		},
+	}
+	gen, err := NewTemplatedGenerator(&template, random.NewSource(1))
+	require.NoError(t, err)
+
+	for range 20 {
+		spans := spansByName(gen.Traces())
+		root := spans["list-articles"][0]
+		sequential := spans["sequential"]
+		for i, span := range sequential {
+			if i == 0 {
+				// the first sequential span isn't delayed by its parallel siblings
+				assert.Less(t, span.StartTimestamp(), spans["parallel"][2].EndTimestamp())
+				continue
+			}
+			assert.Equal(t, sequential[i-1].EndTimestamp(), span.StartTimestamp())
+		}
+
+		// parallel siblings overlap
+		parallel := spans["parallel"]
+		assert.Less(t, parallel[1].StartTimestamp(), parallel[0].EndTimestamp())
+		// sequential repetitions share the duration of the parent
+		rootDuration := root.EndTimestamp() - root.StartTimestamp()
+		last := sequential[len(sequential)-1]
+		assert.LessOrEqual(t, last.EndTimestamp()-sequential[0].StartTimestamp(), rootDuration)
+	}
+}
+
+func TestTemplatedGenerator_SequentialSiblings(t *testing.T) {
+	template := TraceTemplate{
+		Spans: []SpanTemplate{
+			{Service: "shop-backend", Name: ptr("checkout")},
+			{Service: "shop-backend", Name: ptr("validate"), Timing: TimingSequential},
+			{Service: "shop-backend", Name: ptr("reserve"), ParentIDX: ptr(0), Timing: TimingSequential, Repeat: &Repeat{Min: 1, Max: 4}},
+			{Service: "shop-backend", Name: ptr("load-stock")},
+			{Service: "shop-backend", Name: ptr("pay"), ParentIDX: ptr(0), Timing: TimingSequential},
+			{Service: "shop-backend", Name: ptr("audit"), ParentIDX: ptr(0)},
+		},
+	}
+	gen, err := NewTemplatedGenerator(&template, random.NewSource(1))
+	require.NoError(t, err)
+
+	for range testRounds {
+		byID := map[pcommon.SpanID]ptrace.Span{}
+		for _, span := range iterSpans(gen.Traces()) {
+			byID[span.SpanID()] = span
+		}
+		// sequential siblings of different templates share the duration of the parent
+		for _, span := range byID {
+			if parent, found := byID[span.ParentSpanID()]; found {
+				assert.LessOrEqual(t, span.EndTimestamp(), parent.EndTimestamp(), "%s ends after %s", span.Name(), parent.Name())
+			}
+		}
+	}
+}
+
+func TestTemplatedGenerator_MixedSiblings(t *testing.T) {
+	template := TraceTemplate{
+		Spans: []SpanTemplate{
+			{Service: "shop-backend", Name: ptr("checkout")},
+			{Service: "shop-backend", Name: ptr("load-stock")},
+			{Service: "shop-backend", Name: ptr("validate"), ParentIDX: ptr(0), Timing: TimingSequential},
+			{Service: "shop-backend", Name: ptr("audit"), ParentIDX: ptr(0)},
+			{Service: "shop-backend", Name: ptr("pay"), ParentIDX: ptr(0), Timing: TimingSequential, Repeat: &Repeat{Min: 2, Max: 2}},
+		},
+	}
+	gen, err := NewTemplatedGenerator(&template, random.NewSource(1))
+	require.NoError(t, err)
+
+	for range testRounds {
+		spans := spansByName(gen.Traces())
+		checkout, loadStock, validate := spans["checkout"][0], spans["load-stock"][0], spans["validate"][0]
+		sequential := append([]ptrace.Span{validate}, spans["pay"]...)
+
+		// parallel siblings don't delay the sequential spans
+		assert.Less(t, validate.StartTimestamp(), loadStock.EndTimestamp())
+		for i, span := range sequential {
+			if i > 0 {
+				assert.GreaterOrEqual(t, span.StartTimestamp(), sequential[i-1].EndTimestamp(), "%s starts before the previous sequential sibling ended", span.Name())
+			}
+			assert.LessOrEqual(t, span.EndTimestamp(), checkout.EndTimestamp(), "%s ends after the parent", span.Name())
+		}
+	}
+}
+
+func TestTemplatedGenerator_RepeatErrors(t *testing.T) {
+	template := TraceTemplate{
+		Defaults: SpanDefaults{ErrorPropagation: ptr(float32(1))},
+		Spans: []SpanTemplate{
+			{Service: "shop-backend", Name: ptr("list-articles")},
+			{Service: "shop-backend", Name: ptr("load-article"), Repeat: &Repeat{Min: 3, Max: 3}, ErrorRate: ptr(float32(0.5))},
+		},
+	}
+	gen, err := NewTemplatedGenerator(&template, random.NewSource(1))
+	require.NoError(t, err)
+
+	var mixed bool
+	for range 20 {
+		spans := spansByName(gen.Traces())
+		failed := slices.ContainsFunc(spans["load-article"], func(s ptrace.Span) bool {
+			return s.Status().Code() == ptrace.StatusCodeError
+		})
+		succeeded := slices.ContainsFunc(spans["load-article"], func(s ptrace.Span) bool {
+			return s.Status().Code() != ptrace.StatusCodeError
+		})
+		mixed = mixed || (failed && succeeded)
+		// the parent fails if any of the repetitions failed
+		assert.Equal(t, failed, spans["list-articles"][0].Status().Code() == ptrace.StatusCodeError)
+	}
+	assert.True(t, mixed, "repetitions should fail independently")
+}
+
+func TestTemplatedGenerator_RepeatInvalid(t *testing.T) {
+	for name, tt := range map[string]struct {
+		span SpanTemplate
+		err  string
+	}{
+		"negative min":   {SpanTemplate{Repeat: &Repeat{Min: -1, Max: 1}}, "repeat min must not be negative"},
+		"max below min":  {SpanTemplate{Repeat: &Repeat{Min: 3, Max: 2}}, "repeat max 2 must be at least 1"},
+		"max zero":       {SpanTemplate{Repeat: &Repeat{}}, "repeat max 0 must be at least 1"},
+		"probability":    {SpanTemplate{Probability: ptr(float32(2))}, "probability must be between 0 and 1"},
+		"unknown timing": {SpanTemplate{Timing: "random"}, `unknown timing "random"`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tt.span.Service = "shop-backend"
+			template := TraceTemplate{Spans: []SpanTemplate{{Service: "shop-backend"}, tt.span}}
+			_, err := NewTemplatedGenerator(&template, nil)
+			assert.ErrorContains(t, err, "invalid span 1: "+tt.err)
+		})
+	}
+
+	for _, root := range []SpanTemplate{
+		{Service: "shop-backend", Repeat: &Repeat{Min: 1, Max: 2}},
+		{Service: "shop-backend", Probability: ptr(float32(0.5))},
+	} {
+		_, err := NewTemplatedGenerator(&TraceTemplate{Spans: []SpanTemplate{root}}, nil)
+		assert.ErrorContains(t, err, "the root span can't be repeated or optional")
+	}
+}
+
+// spansByName returns the spans of a trace by name in the order they were generated.
+func spansByName(traces ptrace.Traces) map[string][]ptrace.Span {
+	spans := map[string][]ptrace.Span{}
+	for _, span := range iterSpans(traces) {
+		spans[span.Name()] = append(spans[span.Name()], span)
+	}
+	return spans
+}
@@ -3,24 +3,31 @@ package tracegen
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/grafana/xk6-client-tracing/pkg/random"
 	"github.com/grafana/xk6-client-tracing/pkg/util"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/ptrace"
+	"google.golang.org/grpc/codes"
 )
 
 // OTelSemantics describes a specific set of OpenTelemetry semantic conventions.
 type OTelSemantics string
 
 const (
-	SemanticsHTTP OTelSemantics = "http"
-	SemanticsDB   OTelSemantics = "db"
+	SemanticsHTTP      OTelSemantics = "http"
+	SemanticsDB        OTelSemantics = "db"
+	SemanticsMessaging OTelSemantics = "messaging"
+	SemanticsRPC       OTelSemantics = "rpc"
 
 	defaultMinDuration                = time.Millisecond * 500
 	defaultMaxDuration                = time.Millisecond * 800
@@ -29,12 +36,6 @@ const (
 	randomAttributeValueSize          = 30
 )
 
-// Range represents and interval with the given upper and lower bound [Max, Min)
-type Range struct {
-	Min int64
-	Max int64
-}
-
 // AttributeParams describe how random attributes should be created.
 type AttributeParams struct {
 	// Count the number of attributes to create.
@@ -55,6 +56,14 @@ type SpanDefaults struct {
 	RandomEvents *EventParams `js:"randomEvents"`
 	// Random links generated for each span
 	RandomLinks *LinkParams `js:"randomLinks"`
+	// ErrorRate the probability between 0 and 1 that a span fails.
+	ErrorRate *float32 `js:"errorRate"`
+	// ErrorPropagation the probability between 0 and 1 that a span fails if one of its children failed.
+	ErrorPropagation *float32 `js:"errorPropagation"`
+	// Gap the time between sequential siblings.
+	Gap *DurationDistribution `js:"gap"`
+	// DurationFromChildren whether the durations of spans are computed from their children.
+	DurationFromChildren bool `js:"durationFromChildren"`
 	// Resource controls the default attributes for all resources.
 	Resource *ResourceTemplate `js:"resource"`
 }
@@ -68,11 +77,12 @@ type SpanTemplate struct {
 	// ParentIDX defines the index of the parent span in TraceTemplate.Spans. ParentIDX must be smaller than the
 	// own index. If empty, the parent is the span with the position directly before this span in TraceTemplate.Spans.
 	ParentIDX *int `js:"parentIdx"`
-	// Duration defines the interval for the generated span duration. If missing, a random duration is generated that
-	// is shorter than the duration of the parent span.
-	Duration *Range `js:"duration"`
+	// Duration defines the distribution of the generated span duration, e.g. a uniform interval or a lognormal
+	// distribution. If missing, a random duration is generated that is shorter than the duration of the parent span.
+	Duration *DurationDistribution `js:"duration"`
 	// AttributeSemantics can be set in order to generate attributes that follow a certain OpenTelemetry semantic
-	// convention. So far only semantic convention for HTTP requests is supported.
+	// convention. Semantic conventions for HTTP requests, gRPC calls, database calls and messaging are supported. Spans with
+	// messaging semantics that call or are called by another service become producer and consumer spans.
 	AttributeSemantics *OTelSemantics `js:"attributeSemantics"`
 	// Attributes that are added to this span.
 	Attributes map[string]interface{} `js:"attributes"`
@@ -87,6 +97,28 @@ type SpanTemplate struct {
 	RandomEvents *EventParams `js:"randomEvents"`
 	// Generate random links for the span
 	RandomLinks *LinkParams `js:"randomLinks"`
+	// ErrorRate the probability between 0 and 1 that the span fails. Failed spans get the error status and an
+	// error status code of their semantic convention, e.g. an HTTP status code >= 500 for HTTP server spans.
+	ErrorRate *float32 `js:"errorRate"`
+	// ErrorPropagation the probability between 0 and 1 that the span fails if one of its children failed. Client
+	// spans always fail if the server span they call failed.
+	ErrorPropagation *float32 `js:"errorPropagation"`
+	// Repeat how often the span and its children are repeated within the parent span. If missing, the span is
+	// created once.
+	Repeat *Repeat `js:"repeat"`
+	// Probability the probability between 0 and 1 that the span and its children are created (default: 1).
+	Probability *float32 `js:"probability"`
+	// Timing whether the span overlaps with its siblings or starts after the previous sequential sibling ended
+	// (default: parallel).
+	Timing SiblingTiming `js:"timing"`
+	// Offset the time between the start of the parent and the start of the span. Sequential spans only use the offset
+	// if they are the first sequential child. If missing, the offset is 5 to 10% of the parent duration.
+	Offset *DurationDistribution `js:"offset"`
+	// Gap the time between the end of the previous sequential sibling and the start of a sequential span (default: 0).
+	Gap *DurationDistribution `js:"gap"`
+	// DurationFromChildren whether the duration of the span is computed from its children instead of the other way
+	// around. The span ends shortly after its last child ended, unless Duration makes it end later.
+	DurationFromChildren *bool `js:"durationFromChildren"`
 	// Resource controls the attributes generated for the resource. Spans with the same Service will have the same
 	// resource. Multiple resource definitions will be merged.
 	Resource *ResourceTemplate `js:"resource"`
@@ -142,9 +174,12 @@ type EventParams struct {
 	RandomAttributes *AttributeParams `js:"randomAttributes"`
 }
 
-// NewTemplatedGenerator creates a new trace generator.
-func NewTemplatedGenerator(template *TraceTemplate) (*TemplatedGenerator, error) {
-	gen := &TemplatedGenerator{}
+// NewTemplatedGenerator creates a new trace generator. If rnd is nil, a randomly seeded Source is used.
+func NewTemplatedGenerator(template *TraceTemplate, rnd *random.Source) (*TemplatedGenerator, error) {
+	if rnd == nil {
+		rnd = random.NewRandomSource()
+	}
+	gen := &TemplatedGenerator{rnd: rnd}
 	err := gen.initialize(template)
 	if err != nil {
 		return nil, fmt.Errorf("fail to create new templated generator: %w", err)
@@ -156,23 +191,60 @@ func NewTemplatedGenerator(template *TraceTemplate) (*TemplatedGenerator, error)
 // The generator interprets the template parameters such that realistically looking traces with consistent
 // spans and attributes are generated.
 type TemplatedGenerator struct {
+	rnd              *random.Source
 	randomAttributes map[string][]interface{}
 	resources        map[string]*internalResourceTemplate
 	spans            []*internalSpanTemplate
 }
 
 type internalSpanTemplate struct {
-	idx                int
-	resource           *internalResourceTemplate
-	parent             *internalSpanTemplate
-	name               string
-	kind               ptrace.SpanKind
-	duration           *Range
-	attributeSemantics *OTelSemantics
-	attributes         map[string]interface{}
-	randomAttributes   map[string][]interface{}
-	events             []internalEventTemplate
-	links              []internalLinkTemplate
+	idx                  int
+	resource             *internalResourceTemplate
+	parent               *internalSpanTemplate
+	callee               *internalSpanTemplate
+	name                 string
+	kind                 ptrace.SpanKind
+	duration             durationSampler
+	attributeSemantics   *OTelSemantics
+	attributes           map[string]interface{}
+	randomAttributes     map[string][]interface{}
+	events               []internalEventTemplate
+	links                []internalLinkTemplate
+	db                   *internalDBTemplate
+	messaging            *internalMessagingTemplate
+	rpc                  *internalRPCTemplate
+	errorRate            float32
+	errorPropagation     float32
+	repeatMin            int
+	repeatMax            int
+	probability          float32
+	timing               SiblingTiming
+	offset               durationSampler
+	gap                  durationSampler
+	durationFromChildren bool
+}
+
+type internalRPCTemplate struct {
+	service     string
+	method      string
+	host        string
+	port        int
+	callsServer bool
+}
+
+type internalDBTemplate struct {
+	system     string
+	namespace  string
+	collection string
+	host       string
+	port       int
+}
+
+type internalMessagingTemplate struct {
+	system      string
+	destination string
+	host        string
+	port        int
 }
 
 type internalResourceTemplate struct {
@@ -202,19 +274,28 @@ type internalEventTemplate struct {
 // Traces implements Generator for TemplatedGenerator
 func (g *TemplatedGenerator) Traces() ptrace.Traces {
 	var (
-		traceID      = random.TraceID()
+		traceID      = g.rnd.TraceID()
 		traceData    = ptrace.NewTraces()
 		resSpanSlice = traceData.ResourceSpans()
 		resSpanMap   = map[string]ptrace.ResourceSpans{}
-		spans        []ptrace.Span
 	)
 
 	randomTraceAttributes := make(map[string]interface{}, len(g.randomAttributes))
-	for k, v := range g.randomAttributes {
-		randomTraceAttributes[k] = random.SelectElement(v)
+	for _, k := range slices.Sorted(maps.Keys(g.randomAttributes)) {
+		v := g.randomAttributes[k]
+		randomTraceAttributes[k] = random.Pick(g.rnd, v)
 	}
 
-	for _, tmpl := range g.spans {
+	instances := g.instances()
+	if len(instances) == 0 {
+		// templates without spans generate empty traces
+		return traceData
+	}
+	g.failures(instances)
+	g.schedule(instances[0], time.Now().Add(-5*time.Second), 1)
+	for _, inst := range instances {
+		tmpl := inst.tmpl
+
 		// get or generate the corresponding ResourceSpans
 		resSpans, found := resSpanMap[tmpl.resource.service]
 		if !found {
@@ -224,20 +305,25 @@ func (g *TemplatedGenerator) Traces() ptrace.Traces {
 		scopeSpans := resSpans.ScopeSpans().At(0)
 
 		// generate new span
-		var parent *ptrace.Span
-		if tmpl.parent != nil {
-			parent = &spans[tmpl.parent.idx]
+		// consumer spans start a new trace that is linked to the producer
+		spanTraceID := traceID
+		if inst.parent != nil {
+			spanTraceID = inst.parent.span.TraceID()
+			if tmpl.kind == ptrace.SpanKindConsumer {
+				spanTraceID = g.rnd.TraceID()
+			}
 		}
-		s := g.generateSpan(scopeSpans, tmpl, parent, traceID)
+		s := g.generateSpan(scopeSpans, inst, spanTraceID)
 
 		// attributes
-		for k, v := range randomTraceAttributes {
+		for _, k := range slices.Sorted(maps.Keys(randomTraceAttributes)) {
+			v := randomTraceAttributes[k]
 			if _, found := s.Attributes().Get(k); !found {
 				_ = s.Attributes().PutEmpty(k).FromRaw(v)
 			}
 		}
 
-		spans = append(spans, s)
+		inst.span = s
 	}
 
 	return traceData
@@ -248,76 +334,84 @@ func (g *TemplatedGenerator) generateResourceSpans(resSpanSlice ptrace.ResourceS
 	resSpans.Resource().Attributes().PutStr("k6", "true")
 	resSpans.Resource().Attributes().PutStr(attrServiceName, tmpl.service)
 
-	for k, v := range tmpl.attributes {
+	for _, k := range slices.Sorted(maps.Keys(tmpl.attributes)) {
+		v := tmpl.attributes[k]
 		_ = resSpans.Resource().Attributes().PutEmpty(k).FromRaw(v)
 	}
-	for k, v := range tmpl.randomAttributes {
-		_ = resSpans.Resource().Attributes().PutEmpty(k).FromRaw(random.SelectElement(v))
+	for _, k := range slices.Sorted(maps.Keys(tmpl.randomAttributes)) {
+		v := tmpl.randomAttributes[k]
+		_ = resSpans.Resource().Attributes().PutEmpty(k).FromRaw(random.Pick(g.rnd, v))
 	}
 
 	scopeSpans := resSpans.ScopeSpans().AppendEmpty()
-	scopeSpans.Scope().SetName("k6-scope-name/" + random.String(15))
-	scopeSpans.Scope().SetVersion("k6-scope-version:v" + strconv.Itoa(random.IntBetween(0, 99)) + "." + strconv.Itoa(random.IntBetween(0, 99)))
+	scopeSpans.Scope().SetName("k6-scope-name/" + g.rnd.String(15))
+	scopeSpans.Scope().SetVersion("k6-scope-version:v" + strconv.Itoa(g.rnd.IntBetween(0, 99)) + "." + strconv.Itoa(g.rnd.IntBetween(0, 99)))
 	return resSpans
 }
 
-func (g *TemplatedGenerator) generateSpan(scopeSpans ptrace.ScopeSpans, tmpl *internalSpanTemplate, parent *ptrace.Span, traceID pcommon.TraceID) ptrace.Span {
+func (g *TemplatedGenerator) generateSpan(scopeSpans ptrace.ScopeSpans, inst *spanInstance, traceID pcommon.TraceID) ptrace.Span {
+	tmpl := inst.tmpl
+	var parent *ptrace.Span
+	if inst.parent != nil {
+		parent = &inst.parent.span
+	}
 	span := scopeSpans.Spans().AppendEmpty()
 
 	span.SetTraceID(traceID)
-	span.SetSpanID(random.SpanID())
-	if parent != nil {
+	span.SetSpanID(g.rnd.SpanID())
+	if parent != nil && tmpl.kind != ptrace.SpanKindConsumer {
 		span.SetParentSpanID(parent.SpanID())
 	}
 	span.SetName(tmpl.name)
 	span.SetKind(tmpl.kind)
 
 	// set start and end time
-	var start time.Time
-	var duration time.Duration
-	if parent == nil {
-		start = time.Now().Add(-5 * time.Second)
-		if tmpl.duration == nil {
-			duration = random.Duration(defaultMinDuration, defaultMaxDuration)
-		}
-	} else {
-		pStart := parent.StartTimestamp().AsTime()
-		pDuration := parent.EndTimestamp().AsTime().Sub(pStart)
-		start = pStart.Add(random.Duration(pDuration/20, pDuration/10))
-		if tmpl.duration == nil {
-			duration = random.Duration(pDuration/2, pDuration-pDuration/10)
-		}
-	}
-	if tmpl.duration != nil {
-		duration = random.Duration(time.Duration(tmpl.duration.Min)*time.Millisecond, time.Duration(tmpl.duration.Max)*time.Millisecond)
-	}
-	end := start.Add(duration)
+	start, end := inst.start, inst.end
+	duration := end.Sub(start)
 	span.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
 	span.SetEndTimestamp(pcommon.NewTimestampFromTime(end))
 
 	// add attributes
-	for k, v := range tmpl.attributes {
+	for _, k := range slices.Sorted(maps.Keys(tmpl.attributes)) {
+		v := tmpl.attributes[k]
 		_ = span.Attributes().PutEmpty(k).FromRaw(v)
 	}
 
-	for k, v := range tmpl.randomAttributes {
-		_ = span.Attributes().PutEmpty(k).FromRaw(random.SelectElement(v))
+	for _, k := range slices.Sorted(maps.Keys(tmpl.randomAttributes)) {
+		v := tmpl.randomAttributes[k]
+		_ = span.Attributes().PutEmpty(k).FromRaw(random.Pick(g.rnd, v))
 	}
 
 	g.generateNetworkAttributes(tmpl, &span, parent)
-	if tmpl.attributeSemantics != nil && *tmpl.attributeSemantics == SemanticsHTTP {
-		g.generateHTTPAttributes(tmpl, &span, parent)
+	if inst.failure == failureOwn || inst.failure == failurePropagated {
+		g.generateErrorAttributes(tmpl, &span)
+	}
+	if tmpl.attributeSemantics != nil {
+		switch *tmpl.attributeSemantics {
+		case SemanticsHTTP:
+			g.generateHTTPAttributes(tmpl, &span, parent)
+		case SemanticsDB:
+			g.generateDBAttributes(tmpl, &span, parent)
+		case SemanticsMessaging:
+			g.generateMessagingAttributes(tmpl, &span, parent)
+		case SemanticsRPC:
+			g.generateRPCAttributes(tmpl, &span, parent)
+		}
+	}
+
+	if inst.failure != failureNone {
+		g.setErrorStatus(&span)
 	}
 
 	// generate events
-	var hasError bool
+	hasError := span.Status().Code() == ptrace.StatusCodeError
 	if st, found := getHTTPStatusCode(span.Attributes()); found {
-		hasError = st >= 400
+		hasError = hasError || st >= 400
 	}
 
 	span.Events().EnsureCapacity(len(tmpl.events))
 	for _, e := range tmpl.events {
-		if e.rate > 0 && random.Float32() > e.rate {
+		if e.rate > 0 && g.rnd.Float32() > e.rate {
 			continue
 		}
 		if e.exceptionOnError && !hasError {
@@ -328,41 +422,51 @@ func (g *TemplatedGenerator) generateSpan(scopeSpans ptrace.ScopeSpans, tmpl *in
 		event.Attributes().EnsureCapacity(len(e.attributes) + len(e.randomAttributes))
 
 		event.SetName(e.name)
-		eventTime := start.Add(random.Duration(0, duration))
+		eventTime := start.Add(g.rnd.Duration(0, duration))
 		event.SetTimestamp(pcommon.NewTimestampFromTime(eventTime))
 
-		for k, v := range e.attributes {
+		for _, k := range slices.Sorted(maps.Keys(e.attributes)) {
+			v := e.attributes[k]
 			_ = event.Attributes().PutEmpty(k).FromRaw(v)
 		}
-		for k, v := range e.randomAttributes {
-			_ = event.Attributes().PutEmpty(k).FromRaw(random.SelectElement(v))
+		for _, k := range slices.Sorted(maps.Keys(e.randomAttributes)) {
+			v := e.randomAttributes[k]
+			_ = event.Attributes().PutEmpty(k).FromRaw(random.Pick(g.rnd, v))
 		}
 	}
 
 	// generate links
+	if tmpl.kind == ptrace.SpanKindConsumer && parent != nil {
+		link := span.Links().AppendEmpty()
+		link.SetTraceID(parent.TraceID())
+		link.SetSpanID(parent.SpanID())
+	}
+
 	span.Links().EnsureCapacity(len(tmpl.links))
 	for _, l := range tmpl.links {
-		if l.rate > 0 && random.Float32() > l.rate {
+		if l.rate > 0 && g.rnd.Float32() > l.rate {
 			continue
 		}
 
 		link := span.Links().AppendEmpty()
 		link.Attributes().EnsureCapacity(len(l.attributes) + len(l.randomAttributes))
-		for k, v := range l.randomAttributes {
-			_ = link.Attributes().PutEmpty(k).FromRaw(random.SelectElement(v))
+		for _, k := range slices.Sorted(maps.Keys(l.randomAttributes)) {
+			v := l.randomAttributes[k]
+			_ = link.Attributes().PutEmpty(k).FromRaw(random.Pick(g.rnd, v))
 		}
-		for k, v := range l.attributes {
+		for _, k := range slices.Sorted(maps.Keys(l.attributes)) {
+			v := l.attributes[k]
 			_ = link.Attributes().PutEmpty(k).FromRaw(v)
 		}
 
 		// default to linking to parent span if exist
 		// TODO: support linking to other existing spans
 		if parent != nil {
-			link.SetTraceID(traceID)
+			link.SetTraceID(parent.TraceID())
 			link.SetSpanID(parent.SpanID())
 		} else {
-			link.SetTraceID(random.TraceID())
-			link.SetSpanID(random.SpanID())
+			link.SetTraceID(g.rnd.TraceID())
+			link.SetSpanID(g.rnd.SpanID())
 		}
 	}
 
@@ -378,7 +482,7 @@ func (g *TemplatedGenerator) generateNetworkAttributes(tmpl *internalSpanTemplat
 	putIfNotExists(span.Attributes(), "net.sock.family", "inet")
 	switch tmpl.kind {
 	case ptrace.SpanKindClient:
-		putIfNotExists(span.Attributes(), "net.peer.port", random.Port())
+		putIfNotExists(span.Attributes(), "net.peer.port", g.rnd.Port())
 	case ptrace.SpanKindServer:
 		putIfNotExists(span.Attributes(), "net.sock.host.addr", tmpl.resource.hostIP)
 		putIfNotExists(span.Attributes(), "net.host.name", tmpl.resource.hostName)
@@ -410,7 +514,7 @@ func (g *TemplatedGenerator) generateHTTPAttributes(tmpl *internalSpanTemplate,
 		if m, found := getHTTPMethod(span.Attributes()); found {
 			method = m
 		} else {
-			method = random.HTTPMethod()
+			method = g.rnd.HTTPMethod()
 			span.Attributes().PutStr(attrHTTPMethod, method)
 		}
 
@@ -418,7 +522,7 @@ func (g *TemplatedGenerator) generateHTTPAttributes(tmpl *internalSpanTemplate,
 		if ct, found := span.Attributes().Get(attrHTTPResponseHeaderContentType); found {
 			contentType = ct.Slice().AsRaw()
 		} else {
-			contentType = random.HTTPContentType()
+			contentType = g.rnd.HTTPContentType()
 			_ = span.Attributes().PutEmptySlice(attrHTTPResponseHeaderContentType).FromRaw(contentType)
 		}
 
@@ -426,7 +530,7 @@ func (g *TemplatedGenerator) generateHTTPAttributes(tmpl *internalSpanTemplate,
 		if st, found := getHTTPStatusCode(span.Attributes()); found {
 			status = st
 		} else {
-			status = random.HTTPStatusSuccess()
+			status = g.rnd.HTTPStatusSuccess()
 			span.Attributes().PutInt(attrHTTPStatusCode, status)
 		}
 		if status >= 500 {
@@ -446,9 +550,9 @@ func (g *TemplatedGenerator) generateHTTPAttributes(tmpl *internalSpanTemplate,
 		span.Attributes().PutStr(attrURLScheme, requestURL.Scheme)
 		span.Attributes().PutStr(attrURLTarget, requestURL.Path)
 
-		putIfNotExists(span.Attributes(), attrHTTPResponseHeaderContentLength, []any{random.IntBetween(100_000, 1_000_000)})
+		putIfNotExists(span.Attributes(), attrHTTPResponseHeaderContentLength, []any{g.rnd.IntBetween(100_000, 1_000_000)})
 		if method == http.MethodPatch || method == http.MethodPost || method == http.MethodPut {
-			putIfNotExists(span.Attributes(), attrHTTPRequestHeaderContentLength, []any{random.IntBetween(10_000, 100_000)})
+			putIfNotExists(span.Attributes(), attrHTTPRequestHeaderContentLength, []any{g.rnd.IntBetween(10_000, 100_000)})
 		}
 
 		if parent != nil && parent.Kind() == ptrace.SpanKindClient {
@@ -469,9 +573,162 @@ func (g *TemplatedGenerator) generateHTTPAttributes(tmpl *internalSpanTemplate,
 	}
 }
 
+func (g *TemplatedGenerator) generateRPCAttributes(tmpl *internalSpanTemplate, span, parent *ptrace.Span) {
+	if tmpl.kind == ptrace.SpanKindInternal || tmpl.rpc == nil {
+		return
+	}
+	attrs := span.Attributes()
+
+	putIfNotExists(attrs, attrRPCSystem, "grpc")
+	putIfNotExists(attrs, attrRPCService, tmpl.rpc.service)
+	putIfNotExists(attrs, attrRPCMethod, tmpl.rpc.method)
+
+	// client spans that call an instrumented server get the remaining attributes from the server span
+	if tmpl.rpc.callsServer {
+		return
+	}
+	putIfNotExists(attrs, attrServerAddress, tmpl.rpc.host)
+	putIfNotExists(attrs, attrServerPort, tmpl.rpc.port)
+
+	var status int64
+	if st, found := attrs.Get(attrRPCGRPCStatusCode); found {
+		status = st.Int()
+	} else {
+		attrs.PutInt(attrRPCGRPCStatusCode, status)
+	}
+	if status != 0 {
+		span.Status().SetCode(ptrace.StatusCodeError)
+		span.Status().SetMessage(codes.Code(status).String())
+	}
+
+	if tmpl.kind == ptrace.SpanKindServer && parent != nil && parent.Kind() == ptrace.SpanKindClient {
+		if status != 0 {
+			parent.Status().SetCode(ptrace.StatusCodeError)
+			parent.Status().SetMessage(codes.Code(status).String())
+		}
+		for _, k := range rpcAttributes {
+			if v, found := attrs.Get(k); found {
+				putIfNotExists(parent.Attributes(), k, v.AsRaw())
+			}
+		}
+	}
+}
+
+func (g *TemplatedGenerator) generateDBAttributes(tmpl *internalSpanTemplate, span, parent *ptrace.Span) {
+	if tmpl.kind == ptrace.SpanKindInternal || tmpl.db == nil {
+		return
+	}
+	attrs := span.Attributes()
+
+	// the database side of a call uses the values of the calling client span
+	var clientAttr pcommon.Map
+	callFromClient := tmpl.kind == ptrace.SpanKindServer && parent != nil && parent.Kind() == ptrace.SpanKindClient
+	if callFromClient {
+		clientAttr = parent.Attributes()
+		for _, k := range dbAttributes {
+			if v, found := clientAttr.Get(k); found {
+				putIfNotExists(attrs, k, v.AsRaw())
+			}
+		}
+	}
+
+	putIfNotExists(attrs, attrDBSystem, tmpl.db.system)
+	putIfNotExists(attrs, attrDBNamespace, tmpl.db.namespace)
+	putIfNotExists(attrs, attrDBCollectionName, tmpl.db.collection)
+	if _, found := attrs.Get(attrDBQueryText); !found {
+		system, _ := attrs.Get(attrDBSystem)
+		collection, _ := attrs.Get(attrDBCollectionName)
+		operation, query := g.rnd.DBQuery(system.Str(), collection.Str())
+		putIfNotExists(attrs, attrDBOperationName, operation)
+		attrs.PutStr(attrDBQueryText, query)
+	}
+	putIfNotExists(attrs, attrServerAddress, tmpl.db.host)
+	putIfNotExists(attrs, attrServerPort, tmpl.db.port)
+
+	var failed bool
+	var message string
+	if st, found := attrs.Get(attrDBResponseStatusCode); found {
+		failed = true
+		message = st.AsString()
+	}
+	if et, found := attrs.Get(attrErrorType); found {
+		failed = true
+		message = et.AsString()
+	}
+	if failed {
+		span.Status().SetCode(ptrace.StatusCodeError)
+		span.Status().SetMessage(message)
+	} else if op, found := attrs.Get(attrDBOperationName); found {
+		switch op.Str() {
+		case "SELECT", "find", "search":
+			putIfNotExists(attrs, attrDBResponseReturnedRows, g.rnd.IntN(100))
+		case "GET", "get":
+			putIfNotExists(attrs, attrDBResponseReturnedRows, g.rnd.IntN(2))
+		}
+	}
+
+	if callFromClient {
+		for _, k := range dbAttributes {
+			if v, found := attrs.Get(k); found {
+				putIfNotExists(clientAttr, k, v.AsRaw())
+			}
+		}
+		if failed {
+			parent.Status().SetCode(ptrace.StatusCodeError)
+			parent.Status().SetMessage(message)
+		}
+	}
+}
+
+func (g *TemplatedGenerator) generateMessagingAttributes(tmpl *internalSpanTemplate, span, parent *ptrace.Span) {
+	if tmpl.kind == ptrace.SpanKindInternal || tmpl.messaging == nil {
+		return
+	}
+	attrs := span.Attributes()
+
+	// the consumer receives the message sent by the producer
+	if tmpl.kind == ptrace.SpanKindConsumer && parent != nil && parent.Kind() == ptrace.SpanKindProducer {
+		for _, k := range messagingAttributes {
+			if v, found := parent.Attributes().Get(k); found {
+				putIfNotExists(attrs, k, v.AsRaw())
+			}
+		}
+	}
+
+	putIfNotExists(attrs, attrMessagingSystem, tmpl.messaging.system)
+	putIfNotExists(attrs, attrMessagingDestinationName, tmpl.messaging.destination)
+	putIfNotExists(attrs, attrServerAddress, tmpl.messaging.host)
+	putIfNotExists(attrs, attrServerPort, tmpl.messaging.port)
+	_, hasID := attrs.Get(attrMessagingMessageID)
+	_, hasCount := attrs.Get(attrMessagingBatchMessageCount)
+	if !hasID && !hasCount {
+		if g.rnd.IntN(2) == 0 {
+			attrs.PutStr(attrMessagingMessageID, g.rnd.MessageID())
+		} else {
+			attrs.PutInt(attrMessagingBatchMessageCount, int64(g.rnd.IntBetween(2, 100)))
+		}
+	}
+	if system, _ := attrs.Get(attrMessagingSystem); system.Str() == "kafka" {
+		putIfNotExists(attrs, attrMessagingDestinationPartitionID, strconv.Itoa(g.rnd.IntN(12)))
+	}
+
+	switch tmpl.kind {
+	case ptrace.SpanKindProducer:
+		putIfNotExists(attrs, attrMessagingOperationType, "send")
+	case ptrace.SpanKindConsumer:
+		putIfNotExists(attrs, attrMessagingOperationType, "process")
+		putIfNotExists(attrs, attrMessagingConsumerGroupName, tmpl.resource.service)
+	default:
+		putIfNotExists(attrs, attrMessagingOperationType, "receive")
+	}
+}
+
 func (g *TemplatedGenerator) initialize(template *TraceTemplate) error {
-	g.resources = map[string]*internalResourceTemplate{}
-	g.randomAttributes = initializeRandomAttributes(template.Defaults.RandomAttributes)
+	// the resources can be shared with other generators, e.g. by the generators of a topology
+	if g.resources == nil {
+		g.resources = map[string]*internalResourceTemplate{}
+	}
+	g.randomAttributes = g.initializeRandomAttributes(template.Defaults.RandomAttributes)
 
 	for i, tmpl := range template.Spans {
 		// span templates must have a service
@@ -516,6 +773,9 @@ func (g *TemplatedGenerator) initialize(template *TraceTemplate) error {
 		if err != nil {
 			return err
 		}
+		if parent != nil && parent.callee == nil && parent.kind == ptrace.SpanKindClient && span.kind == ptrace.SpanKindServer {
+			parent.callee = span
+		}
 		g.spans = append(g.spans, span)
 	}
 
@@ -526,8 +786,8 @@ func (g *TemplatedGenerator) initializeResource(tmpl *SpanTemplate, defaults *Sp
 	res := internalResourceTemplate{
 		service:   tmpl.Service,
 		hostName:  fmt.Sprintf("%s.local", tmpl.Service),
-		hostIP:    random.IPAddr(),
-		hostPort:  random.Port(),
+		hostIP:    g.rnd.IPAddr(),
+		hostPort:  g.rnd.Port(),
 		transport: "ip_tcp",
 	}
 
@@ -537,7 +797,7 @@ func (g *TemplatedGenerator) initializeResource(tmpl *SpanTemplate, defaults *Sp
 	}
 
 	if tmpl.Resource != nil {
-		res.randomAttributes = initializeRandomAttributes(tmpl.Resource.RandomAttributes)
+		res.randomAttributes = g.initializeRandomAttributes(tmpl.Resource.RandomAttributes)
 		res.attributes = tmpl.Resource.Attributes
 	}
 
@@ -550,7 +810,7 @@ func (g *TemplatedGenerator) amendInitializedResource(res *internalResourceTempl
 	}
 
 	if tmpl.Resource.RandomAttributes != nil {
-		randAttr := initializeRandomAttributes(tmpl.Resource.RandomAttributes)
+		randAttr := g.initializeRandomAttributes(tmpl.Resource.RandomAttributes)
 		res.randomAttributes = util.MergeMaps(res.randomAttributes, randAttr)
 	}
 	if tmpl.Resource.Attributes != nil {
@@ -564,7 +824,6 @@ func (g *TemplatedGenerator) initializeSpan(idx int, parent *internalSpanTemplat
 		idx:                idx,
 		parent:             parent,
 		resource:           res,
-		duration:           tmpl.Duration,
 		attributeSemantics: tmpl.AttributeSemantics,
 	}
 
@@ -572,22 +831,62 @@ func (g *TemplatedGenerator) initializeSpan(idx int, parent *internalSpanTemplat
 	if span.attributeSemantics == nil {
 		span.attributeSemantics = defaults.AttributeSemantics
 	}
+	semantics := span.attributeSemantics
 	span.attributes = util.MergeMaps(defaults.Attributes, tmpl.Attributes)
+	if err := normalizeGRPCStatusCode(span.attributes); err != nil {
+		return nil, fmt.Errorf("invalid span %d: %w", idx, err)
+	}
 
 	// set span name
 	if tmpl.Name != nil {
 		span.name = *tmpl.Name
 	} else {
-		span.name = random.Operation()
+		span.name = g.rnd.Operation()
 	}
 
-	kind, err := initializeSpanKind(parent, tmpl, child)
+	kind, err := initializeSpanKind(parent, tmpl, child, semantics)
 	if err != nil {
 		return nil, err
 	}
 	span.kind = kind
 
-	span.randomAttributes = initializeRandomAttributes(tmpl.RandomAttributes)
+	span.errorRate, err = probability("errorRate", tmpl.ErrorRate, defaults.ErrorRate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid span %d: %w", idx, err)
+	}
+	span.errorPropagation, err = probability("errorPropagation", tmpl.ErrorPropagation, defaults.ErrorPropagation)
+	if err != nil {
+		return nil, fmt.Errorf("invalid span %d: %w", idx, err)
+	}
+
+	err = initializeRepeat(&span, tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid span %d: %w", idx, err)
+	}
+	err = initializeTiming(&span, defaults, tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid span %d: %w", idx, err)
+	}
+
+	if tmpl.Duration != nil {
+		span.duration, err = newDurationSampler(tmpl.Duration)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration of span %d: %w", idx, err)
+		}
+	}
+
+	if semantics != nil {
+		switch *semantics {
+		case SemanticsDB:
+			span.db = g.initializeDB(&span, child)
+		case SemanticsMessaging:
+			span.messaging = g.initializeMessaging(&span, parent, child)
+		case SemanticsRPC:
+			span.rpc = g.initializeRPC(&span, child)
+		}
+	}
+
+	span.randomAttributes = g.initializeRandomAttributes(tmpl.RandomAttributes)
 
 	// initialize links for span
 	span.links = g.initializeLinks(tmpl.Links, tmpl.RandomLinks, defaults.RandomLinks)
@@ -598,7 +897,151 @@ func (g *TemplatedGenerator) initializeSpan(idx int, parent *internalSpanTemplat
 	return &span, nil
 }
 
-func initializeSpanKind(parent *internalSpanTemplate, tmpl, child *SpanTemplate) (ptrace.SpanKind, error) {
+// initializeDB determines the database a span talks to. The database system is derived from the service of
+// the called span or the span's own service if it is the database itself, otherwise a random system is used.
+func (g *TemplatedGenerator) initializeDB(span *internalSpanTemplate, child *SpanTemplate) *internalDBTemplate {
+	var db internalDBTemplate
+
+	system, found := random.DBSystem(span.resource.service)
+	if span.kind == ptrace.SpanKindServer {
+		db.host = span.resource.hostName
+	} else if child != nil && child.Service != span.resource.service {
+		system, found = random.DBSystem(child.Service)
+		db.host = fmt.Sprintf("%s.local", child.Service)
+	}
+	if !found {
+		system, _ = random.DBSystem(g.rnd.DBService())
+	}
+	if db.host == "" {
+		db.host = fmt.Sprintf("%s.local", system)
+	}
+
+	db.system = system
+	db.port = g.rnd.DBPort(system)
+	db.namespace = g.rnd.DBNamespace(system)
+	db.collection = g.resourceFromSpanName(span.name)
+
+	return &db
+}
+
+// initializeMessaging determines the messaging system and destination of a span. A consumer uses the same
+// destination as its producer, so that both spans refer to the same queue or topic.
+func (g *TemplatedGenerator) initializeMessaging(span *internalSpanTemplate, parent *internalSpanTemplate, child *SpanTemplate) *internalMessagingTemplate {
+	if span.kind == ptrace.SpanKindConsumer && parent != nil && parent.messaging != nil {
+		m := *parent.messaging
+		return &m
+	}
+
+	system, found := random.MessagingSystem(span.resource.service)
+	if !found && child != nil {
+		system, found = random.MessagingSystem(child.Service)
+	}
+	if !found {
+		system = g.rnd.MessagingService()
+	}
+
+	return &internalMessagingTemplate{
+		system:      system,
+		destination: g.resourceFromSpanName(span.name),
+		host:        fmt.Sprintf("%s.local", strings.ReplaceAll(system, "_", "-")),
+		port:        g.rnd.MessagingPort(system),
+	}
+}
+
+// initializeRPC determines the gRPC service and method of a span. Client spans use the service and method of the
+// called span if it belongs to another service.
+func (g *TemplatedGenerator) initializeRPC(span *internalSpanTemplate, child *SpanTemplate) *internalRPCTemplate {
+	rpc := internalRPCTemplate{
+		service: rpcServiceName(span.resource.service),
+		method:  rpcMethodName(span.name),
+		host:    span.resource.hostName,
+		port:    span.resource.hostPort,
+	}
+
+	if span.kind == ptrace.SpanKindClient {
+		rpc.host = ""
+		if child != nil && child.Service != span.resource.service {
+			rpc.service = rpcServiceName(child.Service)
+			if child.Name != nil {
+				rpc.method = rpcMethodName(*child.Name)
+			}
+			rpc.callsServer = true
+		} else {
+			rpc.host = fmt.Sprintf("%s.local", span.resource.service)
+			rpc.port = g.rnd.Port()
+		}
+	}
+
+	return &rpc
+}
+
+// rpcServiceName converts a service name like "article-service" into a fully qualified gRPC service name like
+// "article.v1.ArticleService".
+func rpcServiceName(service string) string {
+	parts := splitName(strings.ToLower(service))
+	if len(parts) > 1 && parts[len(parts)-1] == "service" {
+		parts = parts[:len(parts)-1]
+	}
+	if len(parts) == 0 {
+		return "Service"
+	}
+	return parts[0] + ".v1." + pascalCase(parts) + "Service"
+}
+
+// rpcMethodName converts a span name like "get-articles" into a gRPC method name like "GetArticles".
+func rpcMethodName(name string) string {
+	return pascalCase(splitName(name))
+}
+
+func pascalCase(parts []string) string {
+	var b strings.Builder
+	for _, p := range parts {
+		r, size := utf8.DecodeRuneInString(p)
+		b.WriteRune(unicode.ToUpper(r))
+		b.WriteString(p[size:])
+	}
+	return b.String()
+}
+
+// normalizeGRPCStatusCode converts a gRPC status code given as string, e.g. "14" or "UNAVAILABLE", into an integer.
+func normalizeGRPCStatusCode(attributes map[string]interface{}) error {
+	s, ok := attributes[attrRPCGRPCStatusCode].(string)
+	if !ok {
+		return nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		attributes[attrRPCGRPCStatusCode] = n
+		return nil
+	}
+	var code codes.Code
+	if err := code.UnmarshalJSON([]byte(strconv.Quote(strings.ToUpper(s)))); err != nil {
+		return fmt.Errorf("invalid %s %q", attrRPCGRPCStatusCode, s)
+	}
+	attributes[attrRPCGRPCStatusCode] = int64(code)
+	return nil
+}
+
+func splitName(name string) []string {
+	return strings.FieldsFunc(name, func(r rune) bool {
+		return r == '-' || r == '_' || r == '.' || r == ' ' || r == '/'
+	})
+}
+
+// resourceFromSpanName uses the last part of a span name like "select-articles" as name for tables, collections
+// or message destinations.
+func (g *TemplatedGenerator) resourceFromSpanName(name string) string {
+	parts := splitName(name)
+	if len(parts) < 2 {
+		return g.rnd.DBCollection()
+	}
+	collection := strings.ToLower(parts[len(parts)-1])
+	if !strings.HasSuffix(collection, "s") {
+		collection += "s"
+	}
+	return collection
+}
+
+func initializeSpanKind(parent *internalSpanTemplate, tmpl, child *SpanTemplate, semantics *OTelSemantics) (ptrace.SpanKind, error) {
 	var kind ptrace.SpanKind
 	if k, found := tmpl.Attributes["span.kind"]; found {
 		kindStr, ok := k.(string)
@@ -623,6 +1066,18 @@ func initializeSpanKind(parent *internalSpanTemplate, tmpl, child *SpanTemplate)
 				kind = ptrace.SpanKindInternal
 			}
 		}
+
+		// calls between services with messaging semantics are asynchronous
+		if semantics != nil && *semantics == SemanticsMessaging {
+			switch kind {
+			case ptrace.SpanKindClient:
+				kind = ptrace.SpanKindProducer
+			case ptrace.SpanKindServer:
+				if parent != nil {
+					kind = ptrace.SpanKindConsumer
+				}
+			}
+		}
 	}
 	return kind, nil
 }
@@ -646,13 +1101,27 @@ func spanKindFromString(s string) ptrace.SpanKind {
 	}
 }
 
+var dbAttributes = []string{
+	attrDBSystem, attrDBNamespace, attrDBCollectionName, attrDBOperationName, attrDBQueryText,
+	attrDBResponseStatusCode, attrDBResponseReturnedRows, attrErrorType, attrServerAddress, attrServerPort,
+}
+
+var rpcAttributes = []string{
+	attrRPCSystem, attrRPCService, attrRPCMethod, attrRPCGRPCStatusCode, attrServerAddress, attrServerPort,
+}
+
+var messagingAttributes = []string{
+	attrMessagingSystem, attrMessagingDestinationName, attrMessagingDestinationPartitionID, attrMessagingMessageID,
+	attrMessagingBatchMessageCount, attrServerAddress, attrServerPort,
+}
+
 func putIfNotExists(m pcommon.Map, k string, v interface{}) {
 	if _, found := m.Get(k); !found {
 		_ = m.PutEmpty(k).FromRaw(v)
 	}
 }
 
-func initializeRandomAttributes(attributeParams *AttributeParams) map[string][]interface{} {
+func (g *TemplatedGenerator) initializeRandomAttributes(attributeParams *AttributeParams) map[string][]interface{} {
 	if attributeParams == nil {
 		return map[string][]interface{}{}
 	}
@@ -664,10 +1133,10 @@ func initializeRandomAttributes(attributeParams *AttributeParams) map[string][]i
 
 	attributes := make(map[string][]interface{}, attributeParams.Count)
 	for i := 0; i < attributeParams.Count; i++ {
-		key := random.K6String(randomAttributeKeySize)
+		key := g.rnd.K6String(randomAttributeKeySize)
 		values := make([]interface{}, 0, *attributeParams.Cardinality)
 		for j := 0; j < *attributeParams.Cardinality; j++ {
-			values = append(values, random.String(randomAttributeValueSize))
+			values = append(values, g.rnd.String(randomAttributeValueSize))
 		}
 		attributes[key] = values
 	}
@@ -681,7 +1150,7 @@ func (g *TemplatedGenerator) initializeEvents(tmplEvents []Event, randomEvents,
 		event := internalEventTemplate{
 			name:             e.Name,
 			attributes:       e.Attributes,
-			randomAttributes: initializeRandomAttributes(e.RandomAttributes),
+			randomAttributes: g.initializeRandomAttributes(e.RandomAttributes),
 		}
 		internalEvents = append(internalEvents, event)
 	}
@@ -700,15 +1169,15 @@ func (g *TemplatedGenerator) initializeEvents(tmplEvents []Event, randomEvents,
 	if randomEvents.Count < 1 {
 		event := internalEventTemplate{
 			rate:             randomEvents.Count,
-			name:             random.EventName(),
-			randomAttributes: initializeRandomAttributes(randomEvents.RandomAttributes),
+			name:             g.rnd.EventName(),
+			randomAttributes: g.initializeRandomAttributes(randomEvents.RandomAttributes),
 		}
 		internalEvents = append(internalEvents, event)
 	} else {
 		for i := 0; i < int(randomEvents.Count); i++ {
 			event := internalEventTemplate{
-				name:             random.EventName(),
-				randomAttributes: initializeRandomAttributes(randomEvents.RandomAttributes),
+				name:             g.rnd.EventName(),
+				randomAttributes: g.initializeRandomAttributes(randomEvents.RandomAttributes),
 			}
 			internalEvents = append(internalEvents, event)
 		}
@@ -724,11 +1193,11 @@ func (g *TemplatedGenerator) initializeEvents(tmplEvents []Event, randomEvents,
 			name: "exception",
 			attributes: map[string]interface{}{
 				"exception.escape":     false,
-				"exception.message":    generateRandomExceptionMsg(),
-				"exception.stacktrace": generateRandomExceptionStackTrace(),
-				"exception.type":       "error.type_" + random.K6String(10),
+				"exception.message":    g.generateRandomExceptionMsg(),
+				"exception.stacktrace": g.generateRandomExceptionStackTrace(),
+				"exception.type":       "error.type_" + g.rnd.K6String(10),
 			},
-			randomAttributes: initializeRandomAttributes(randomEvents.RandomAttributes),
+			randomAttributes: g.initializeRandomAttributes(randomEvents.RandomAttributes),
 			exceptionOnError: randomEvents.ExceptionOnError,
 		}
 		internalEvents = append(internalEvents, event)
@@ -738,11 +1207,11 @@ func (g *TemplatedGenerator) initializeEvents(tmplEvents []Event, randomEvents,
 				name: "exception",
 				attributes: map[string]interface{}{
 					"exception.escape":     false,
-					"exception.message":    generateRandomExceptionMsg(),
-					"exception.stacktrace": generateRandomExceptionStackTrace(),
-					"exception.type":       "error.type_" + random.K6String(10),
+					"exception.message":    g.generateRandomExceptionMsg(),
+					"exception.stacktrace": g.generateRandomExceptionStackTrace(),
+					"exception.type":       "error.type_" + g.rnd.K6String(10),
 				},
-				randomAttributes: initializeRandomAttributes(randomEvents.RandomAttributes),
+				randomAttributes: g.initializeRandomAttributes(randomEvents.RandomAttributes),
 				exceptionOnError: randomEvents.ExceptionOnError,
 			}
 			internalEvents = append(internalEvents, event)
@@ -752,17 +1221,17 @@ func (g *TemplatedGenerator) initializeEvents(tmplEvents []Event, randomEvents,
 	return internalEvents
 }
 
-func generateRandomExceptionMsg() string {
-	return "error: " + random.K6String(20)
+func (g *TemplatedGenerator) generateRandomExceptionMsg() string {
+	return "error: " + g.rnd.K6String(20)
 }
 
-func generateRandomExceptionStackTrace() string {
+func (g *TemplatedGenerator) generateRandomExceptionStackTrace() string {
 	var (
 		panics    = []string{"runtime error: index out of range", "runtime error: can't divide by 0"}
 		functions = []string{"main.main()", "trace.makespan()", "account.login()", "payment.collect()"}
 	)
 
-	return "panic: " + random.SelectElement(panics) + "\n" + random.SelectElement(functions)
+	return "panic: " + random.Pick(g.rnd, panics) + "\n" + random.Pick(g.rnd, functions)
 }
 
 func (g *TemplatedGenerator) initializeLinks(linkTemplates []Link, randomLinks, defaultRandomLinks *LinkParams) []internalLinkTemplate {
@@ -771,7 +1240,7 @@ func (g *TemplatedGenerator) initializeLinks(linkTemplates []Link, randomLinks,
 	for _, lt := range linkTemplates {
 		link := internalLinkTemplate{
 			attributes:       lt.Attributes,
-			randomAttributes: initializeRandomAttributes(lt.RandomAttributes),
+			randomAttributes: g.initializeRandomAttributes(lt.RandomAttributes),
 		}
 		internalLinks = append(internalLinks, link)
 	}
@@ -789,13 +1258,13 @@ func (g *TemplatedGenerator) initializeLinks(linkTemplates []Link, randomLinks,
 	if randomLinks.Count < 1 {
 		link := internalLinkTemplate{
 			rate:             randomLinks.Count,
-			randomAttributes: initializeRandomAttributes(randomLinks.RandomAttributes),
+			randomAttributes: g.initializeRandomAttributes(randomLinks.RandomAttributes),
 		}
 		internalLinks = append(internalLinks, link)
 	} else {
 		for i := 0; i < int(randomLinks.Count); i++ {
 			link := internalLinkTemplate{
-				randomAttributes: initializeRandomAttributes(randomLinks.RandomAttributes),
+				randomAttributes: g.initializeRandomAttributes(randomLinks.RandomAttributes),
 			}
 			internalLinks = append(internalLinks, link)
 		}
@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/grafana/xk6-client-tracing/pkg/random"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/pdata/pcommon"
@@ -30,7 +31,7 @@ func TestTemplatedGenerator_Traces(t *testing.T) {
 
 	for _, semantics := range attributeSemantics {
 		template.Defaults.AttributeSemantics = &semantics
-		gen, err := NewTemplatedGenerator(&template)
+		gen, err := NewTemplatedGenerator(&template, nil)
 		assert.NoError(t, err)
 
 		for range testRounds {
@@ -57,6 +58,12 @@ func TestTemplatedGenerator_Traces(t *testing.T) {
 	}
 }
 
+func TestTemplatedGenerator_Empty(t *testing.T) {
+	gen, err := NewTemplatedGenerator(&TraceTemplate{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, gen.Traces().SpanCount())
+}
+
 func TestTemplatedGenerator_Resource(t *testing.T) {
 	template := TraceTemplate{
 		Defaults: SpanDefaults{
@@ -78,7 +85,7 @@ func TestTemplatedGenerator_Resource(t *testing.T) {
 		},
 	}
 
-	gen, err := NewTemplatedGenerator(&template)
+	gen, err := NewTemplatedGenerator(&template, nil)
 	require.NoError(t, err)
 
 	for range testRounds {
@@ -101,6 +108,184 @@ func TestTemplatedGenerator_Resource(t *testing.T) {
 	}
 }
 
+func TestTemplatedGenerator_DB(t *testing.T) {
+	semantics := SemanticsDB
+	template := TraceTemplate{
+		Defaults: SpanDefaults{AttributeSemantics: &semantics},
+		Spans: []SpanTemplate{
+			{Service: "article-service", Name: ptr("select-articles")},
+			{Service: "postgres", Name: ptr("query-articles")},
+			{Service: "article-service", Name: ptr("cache-articles"), ParentIDX: ptr(0)},
+			{Service: "redis", Name: ptr("get-article"), Attributes: map[string]interface{}{attrDBResponseStatusCode: "ERR"}},
+			{Service: "article-service", Name: ptr("select-comments"), ParentIDX: ptr(0), Attributes: map[string]interface{}{"span.kind": "client"}},
+		},
+	}
+
+	gen, err := NewTemplatedGenerator(&template, nil)
+	require.NoError(t, err)
+
+	for range testRounds {
+		spans := map[string]ptrace.Span{}
+		for _, span := range iterSpans(gen.Traces()) {
+			spans[span.Name()] = span
+			if span.Kind() == ptrace.SpanKindInternal {
+				continue
+			}
+			for _, k := range []string{attrDBSystem, attrDBNamespace, attrDBOperationName, attrDBQueryText, attrServerAddress, attrServerPort} {
+				_, found := span.Attributes().Get(k)
+				assert.True(t, found, "attribute %s not found in span %s", k, span.Name())
+			}
+			requireAttributeEqual(t, span.Attributes(), attrDBCollectionName, strings.TrimSuffix(strings.Split(span.Name(), "-")[1], "s")+"s")
+		}
+
+		client, server := spans["select-articles"], spans["query-articles"]
+		assert.Equal(t, ptrace.SpanKindClient, client.Kind())
+		assert.Equal(t, ptrace.SpanKindServer, server.Kind())
+		requireAttributeEqual(t, client.Attributes(), attrDBSystem, "postgresql")
+		requireAttributeEqual(t, client.Attributes(), attrServerAddress, "postgres.local")
+		requireAttributeEqual(t, client.Attributes(), attrServerPort, int64(5432))
+		query, _ := client.Attributes().Get(attrDBQueryText)
+		requireAttributeEqual(t, server.Attributes(), attrDBQueryText, query.Str())
+		assert.Equal(t, ptrace.StatusCodeUnset, client.Status().Code())
+
+		requireAttributeEqual(t, spans["cache-articles"].Attributes(), attrDBSystem, "redis")
+		assert.Equal(t, ptrace.StatusCodeError, spans["cache-articles"].Status().Code())
+		assert.Equal(t, ptrace.StatusCodeError, spans["get-article"].Status().Code())
+
+		system, _ := spans["select-comments"].Attributes().Get(attrDBSystem)
+		assert.NotEmpty(t, system.Str())
+	}
+}
+
+func TestTemplatedGenerator_Messaging(t *testing.T) {
+	semantics := SemanticsMessaging
+	template := TraceTemplate{
+		Spans: []SpanTemplate{
+			{Service: "shop-backend", Name: ptr("checkout")},
+			{Service: "shop-backend", Name: ptr("publish-orders"), AttributeSemantics: &semantics},
+			{Service: "order-worker", Name: ptr("process-orders"), AttributeSemantics: &semantics},
+			{Service: "order-worker", Name: ptr("store-order")},
+		},
+	}
+
+	gen, err := NewTemplatedGenerator(&template, nil)
+	require.NoError(t, err)
+
+	for range testRounds {
+		spans := map[string]ptrace.Span{}
+		for _, span := range iterSpans(gen.Traces()) {
+			spans[span.Name()] = span
+		}
+		require.Len(t, spans, 4)
+
+		root, producer, consumer, child := spans["checkout"], spans["publish-orders"], spans["process-orders"], spans["store-order"]
+		assert.Equal(t, ptrace.SpanKindProducer, producer.Kind())
+		assert.Equal(t, ptrace.SpanKindConsumer, consumer.Kind())
+
+		// producer is part of the original trace
+		assert.Equal(t, root.TraceID(), producer.TraceID())
+		assert.Equal(t, root.SpanID(), producer.ParentSpanID())
+
+		// consumer starts a new trace and is linked to the producer
+		assert.NotEqual(t, producer.TraceID(), consumer.TraceID())
+		assert.True(t, consumer.ParentSpanID().IsEmpty())
+		require.GreaterOrEqual(t, consumer.Links().Len(), 1)
+		assert.Equal(t, producer.TraceID(), consumer.Links().At(0).TraceID())
+		assert.Equal(t, producer.SpanID(), consumer.Links().At(0).SpanID())
+		assert.False(t, consumer.StartTimestamp().AsTime().Before(producer.EndTimestamp().AsTime()))
+
+		// spans below the consumer belong to the new trace
+		assert.Equal(t, consumer.TraceID(), child.TraceID())
+		assert.Equal(t, consumer.SpanID(), child.ParentSpanID())
+
+		for _, k := range []string{attrMessagingSystem, attrMessagingDestinationName, attrServerAddress} {
+			v, found := producer.Attributes().Get(k)
+			require.True(t, found, "attribute %s not found", k)
+			requireAttributeEqual(t, consumer.Attributes(), k, v.AsRaw())
+		}
+		requireAttributeEqual(t, producer.Attributes(), attrMessagingDestinationName, "orders")
+		requireAttributeEqual(t, producer.Attributes(), attrMessagingOperationType, "send")
+		requireAttributeEqual(t, consumer.Attributes(), attrMessagingOperationType, "process")
+		requireAttributeEqual(t, consumer.Attributes(), attrMessagingConsumerGroupName, "order-worker")
+		if id, found := producer.Attributes().Get(attrMessagingMessageID); found {
+			requireAttributeEqual(t, consumer.Attributes(), attrMessagingMessageID, id.Str())
+		} else {
+			count, found := producer.Attributes().Get(attrMessagingBatchMessageCount)
+			require.True(t, found, "neither message id nor batch count found")
+			requireAttributeEqual(t, consumer.Attributes(), attrMessagingBatchMessageCount, count.Int())
+		}
+	}
+}
+
+func TestTemplatedGenerator_RPC(t *testing.T) {
+	semantics := SemanticsRPC
+	template := TraceTemplate{
+		Defaults: SpanDefaults{AttributeSemantics: &semantics},
+		Spans: []SpanTemplate{
+			{Service: "shop-backend", Name: ptr("list-articles")},
+			{Service: "article-service", Name: ptr("list-articles")},
+			{Service: "shop-backend", Name: ptr("get-stock"), ParentIDX: ptr(0)},
+			{Service: "stock-service", Name: ptr("get-stock"), Attributes: map[string]interface{}{attrRPCGRPCStatusCode: 14}},
+		},
+	}
+
+	gen, err := NewTemplatedGenerator(&template, nil)
+	require.NoError(t, err)
+
+	for range testRounds {
+		var spans []ptrace.Span
+		for _, span := range iterSpans(gen.Traces()) {
+			spans = append(spans, span)
+		}
+		require.Len(t, spans, 4)
+
+		client, server := findSpan(t, spans, "list-articles", ptrace.SpanKindClient), findSpan(t, spans, "list-articles", ptrace.SpanKindServer)
+		for _, span := range []ptrace.Span{client, server} {
+			requireAttributeEqual(t, span.Attributes(), attrRPCSystem, "grpc")
+			requireAttributeEqual(t, span.Attributes(), attrRPCService, "article.v1.ArticleService")
+			requireAttributeEqual(t, span.Attributes(), attrRPCMethod, "ListArticles")
+			requireAttributeEqual(t, span.Attributes(), attrRPCGRPCStatusCode, int64(0))
+			requireAttributeEqual(t, span.Attributes(), attrServerAddress, "article-service.local")
+			assert.Equal(t, ptrace.StatusCodeUnset, span.Status().Code())
+		}
+
+		client, server = findSpan(t, spans, "get-stock", ptrace.SpanKindClient), findSpan(t, spans, "get-stock", ptrace.SpanKindServer)
+		for _, span := range []ptrace.Span{client, server} {
+			requireAttributeEqual(t, span.Attributes(), attrRPCService, "stock.v1.StockService")
+			requireAttributeEqual(t, span.Attributes(), attrRPCGRPCStatusCode, int64(14))
+			assert.Equal(t, ptrace.StatusCodeError, span.Status().Code())
+			assert.Equal(t, "Unavailable", span.Status().Message())
+		}
+	}
+}
+
+func TestTemplatedGenerator_RPCStatusCode(t *testing.T) {
+	semantics := SemanticsRPC
+	for status, expected := range map[string]int64{"5": 5, "UNAVAILABLE": 14, "not_found": 5} {
+		template := TraceTemplate{
+			Defaults: SpanDefaults{AttributeSemantics: &semantics},
+			Spans: []SpanTemplate{
+				{Service: "shop-backend", Name: ptr("get-stock"), Attributes: map[string]interface{}{attrRPCGRPCStatusCode: status}},
+			},
+		}
+		gen, err := NewTemplatedGenerator(&template, nil)
+		require.NoError(t, err)
+
+		span := gen.Traces().ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+		requireAttributeEqual(t, span.Attributes(), attrRPCGRPCStatusCode, expected)
+		assert.Equal(t, ptrace.StatusCodeError, span.Status().Code())
+	}
+
+	template := TraceTemplate{Spans: []SpanTemplate{{Service: "shop-backend", Attributes: map[string]interface{}{attrRPCGRPCStatusCode: "broken"}}}}
+	_, err := NewTemplatedGenerator(&template, nil)
+	assert.ErrorContains(t, err, `invalid span 0: invalid rpc.grpc.status_code "broken"`)
+}
+
+func TestRPCNames(t *testing.T) {
+	assert.Equal(t, "ÜbersichtLaden", rpcMethodName("übersicht-laden"))
+	assert.Equal(t, "shop.v1.ShopBackendService", rpcServiceName("shop-backend"))
+}
+
 func TestTemplatedGenerator_EventsLinks(t *testing.T) {
 	attributeSemantics := []OTelSemantics{SemanticsHTTP}
 	template := TraceTemplate{
@@ -122,7 +307,7 @@ func TestTemplatedGenerator_EventsLinks(t *testing.T) {
 
 	for _, semantics := range attributeSemantics {
 		template.Defaults.AttributeSemantics = &semantics
-		gen, err := NewTemplatedGenerator(&template)
+		gen, err := NewTemplatedGenerator(&template, nil)
 		assert.NoError(t, err)
 
 		for range testRounds {
@@ -196,6 +381,37 @@ func TestTemplatedGenerator_EventsLinks(t *testing.T) {
 	}
 }
 
+func TestTemplatedGenerator_Seed(t *testing.T) {
+	template := TraceTemplate{
+		Defaults: SpanDefaults{RandomAttributes: &AttributeParams{Count: 3}},
+		Spans: []SpanTemplate{
+			{Service: "test-service", Name: ptr("perform-test")},
+			{Service: "test-service", Name: ptr("get_test_data")},
+			{Service: "test-data", AttributeSemantics: ptr(SemanticsDB), RandomAttributes: &AttributeParams{Count: 2}},
+		},
+	}
+
+	gen1, err := NewTemplatedGenerator(&template, random.NewSource(42))
+	require.NoError(t, err)
+	gen2, err := NewTemplatedGenerator(&template, random.NewSource(42))
+	require.NoError(t, err)
+
+	for range testRounds {
+		assert.JSONEq(t, tracesWithoutTimestamps(t, gen1.Traces()), tracesWithoutTimestamps(t, gen2.Traces()))
+	}
+}
+
+func findSpan(t *testing.T, spans []ptrace.Span, name string, kind ptrace.SpanKind) ptrace.Span {
+	t.Helper()
+	for _, span := range spans {
+		if span.Name() == name && span.Kind() == kind {
+			return span
+		}
+	}
+	require.Fail(t, "span not found", "no span %s with kind %s", name, kind)
+	return ptrace.Span{}
+}
+
 func iterSpans(traces ptrace.Traces) func(func(i int, e ptrace.Span) bool) {
 	count := 0
 	return func(f func(i int, e ptrace.Span) bool) {
@@ -266,6 +482,23 @@ func countAttributes(attributes pcommon.Map, prefixes ...string) int {
 	return count
 }
 
+// tracesWithoutTimestamps returns the traces as JSON with all timestamps removed, since they depend on the time
+// of generation.
+func tracesWithoutTimestamps(t *testing.T, traces ptrace.Traces) string {
+	t.Helper()
+
+	for _, span := range iterSpans(traces) {
+		span.SetStartTimestamp(0)
+		span.SetEndTimestamp(0)
+		for i := 0; i < span.Events().Len(); i++ {
+			span.Events().At(i).SetTimestamp(0)
+		}
+	}
+	data, err := (&ptrace.JSONMarshaler{}).MarshalTraces(traces)
+	require.NoError(t, err)
+	return string(data)
+}
+
 func ptr[T any](v T) *T {
 	return &v
 }
@@ -0,0 +1,144 @@
+package tracegen
+
+import (
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// SiblingTiming describes when a span starts relative to its siblings.
+type SiblingTiming string
+
+const (
+	// TimingParallel the span starts shortly after its parent and overlaps with its siblings
+	TimingParallel SiblingTiming = "parallel"
+	// TimingSequential the span starts when the previous sequential sibling ended, e.g. for loops of database queries
+	TimingSequential SiblingTiming = "sequential"
+)
+
+// initializeTiming validates the parameters that define when a span starts and how its duration is computed.
+func initializeTiming(span *internalSpanTemplate, defaults *SpanDefaults, tmpl *SpanTemplate) error {
+	switch tmpl.Timing {
+	case "", TimingParallel:
+		span.timing = TimingParallel
+	case TimingSequential:
+		span.timing = TimingSequential
+	default:
+		return fmt.Errorf("unknown timing %q", tmpl.Timing)
+	}
+
+	var err error
+	if tmpl.Offset != nil {
+		span.offset, err = newDurationSampler(tmpl.Offset)
+		if err != nil {
+			return fmt.Errorf("invalid offset: %w", err)
+		}
+	}
+	gap := tmpl.Gap
+	if gap == nil {
+		gap = defaults.Gap
+	}
+	if gap != nil {
+		span.gap, err = newDurationSampler(gap)
+		if err != nil {
+			return fmt.Errorf("invalid gap: %w", err)
+		}
+	}
+
+	span.durationFromChildren = defaults.DurationFromChildren
+	if tmpl.DurationFromChildren != nil {
+		span.durationFromChildren = *tmpl.DurationFromChildren
+	}
+	return nil
+}
+
+// schedule decides the start and end of a span and all of its children. Parents are scheduled before their
+// children, but the end of spans with durationFromChildren is only known after their children were scheduled.
+// Sequential spans share the remaining duration of the parent with the sequential siblings that follow them,
+// siblings is the number of these spans including the span itself.
+func (g *TemplatedGenerator) schedule(inst *spanInstance, start time.Time, siblings int) {
+	tmpl := inst.tmpl
+	inst.start = start
+
+	var duration time.Duration
+	switch {
+	case tmpl.duration != nil:
+		duration = tmpl.duration(g.rnd)
+	case inst.parent == nil:
+		duration = g.rnd.Duration(defaultMinDuration, defaultMaxDuration)
+	default:
+		pDuration := inst.parent.end.Sub(inst.parent.start)
+		if tmpl.timing == TimingSequential {
+			// the last sequential sibling ends before the parent, unless gaps or fixed durations prevent it
+			pDuration = max(inst.parent.end.Sub(start), 0) / time.Duration(siblings)
+		}
+		duration = g.rnd.Duration(pDuration/2, pDuration-pDuration/10)
+	}
+	inst.end = start.Add(duration)
+
+	// messages are processed after they were sent, so consumers are scheduled after the end of the producer is known.
+	// Sequential spans start after the previous sequential sibling, parallel siblings don't delay them.
+	var previous *spanInstance
+	var consumers []*spanInstance
+	hasChildren := false
+	sequential := 0
+	for _, child := range inst.children {
+		if child.tmpl.kind != ptrace.SpanKindConsumer && child.tmpl.timing == TimingSequential {
+			sequential++
+		}
+	}
+	for _, child := range inst.children {
+		if child.tmpl.kind == ptrace.SpanKindConsumer {
+			consumers = append(consumers, child)
+			continue
+		}
+		hasChildren = true
+
+		var childStart time.Time
+		switch {
+		case child.tmpl.timing == TimingSequential && previous != nil:
+			childStart = previous.end
+			if child.tmpl.gap != nil {
+				childStart = childStart.Add(child.tmpl.gap(g.rnd))
+			}
+		case child.tmpl.offset != nil:
+			childStart = start.Add(child.tmpl.offset(g.rnd))
+		default:
+			childStart = start.Add(g.randomOffset(duration))
+		}
+		g.schedule(child, childStart, sequential)
+		if child.tmpl.timing == TimingSequential {
+			sequential--
+			previous = child
+		}
+	}
+
+	if tmpl.durationFromChildren && hasChildren {
+		var childrenEnd time.Time
+		for _, child := range inst.children {
+			if child.tmpl.kind != ptrace.SpanKindConsumer && child.end.After(childrenEnd) {
+				childrenEnd = child.end
+			}
+		}
+		// a configured duration is the minimum duration of the span, otherwise it only depends on the children
+		end := childrenEnd.Add(g.randomOffset(childrenEnd.Sub(start)))
+		if tmpl.duration == nil || end.After(inst.end) {
+			inst.end = end
+		}
+	}
+
+	for _, consumer := range consumers {
+		pDuration := inst.end.Sub(inst.start)
+		g.schedule(consumer, inst.end.Add(g.rnd.Duration(pDuration/20, pDuration/2)), 1)
+	}
+}
+
+// randomOffset returns a random offset between 5% and 10% of a duration, which is used to delay the start of
+// children and the end of spans after their children ended.
+func (g *TemplatedGenerator) randomOffset(duration time.Duration) time.Duration {
+	if duration/10 <= duration/20 {
+		return duration / 20
+	}
+	return g.rnd.Duration(duration/20, duration/10)
+}
@@ -0,0 +1,90 @@
+package tracegen
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/grafana/xk6-client-tracing/pkg/random"
+)
+
+func TestTemplatedGenerator_Gap(t *testing.T) {
+	template := TraceTemplate{
+		Defaults: SpanDefaults{Gap: &DurationDistribution{Type: DistributionFixed, Value: 10}},
+		Spans: []SpanTemplate{
+			{Service: "shop-backend", Name: ptr("checkout")},
+			{Service: "shop-backend", Name: ptr("validate"), Timing: TimingSequential, Offset: &DurationDistribution{Type: DistributionFixed, Value: 20}},
+			{Service: "shop-backend", Name: ptr("reserve"), ParentIDX: ptr(0), Timing: TimingSequential},
+			{Service: "shop-backend", Name: ptr("pay"), ParentIDX: ptr(0), Timing: TimingSequential, Gap: &DurationDistribution{Min: 30, Max: 40}},
+		},
+	}
+	gen, err := NewTemplatedGenerator(&template, random.NewSource(1))
+	require.NoError(t, err)
+
+	for range testRounds {
+		spans := spansByName(gen.Traces())
+		checkout, validate, reserve, pay := spans["checkout"][0], spans["validate"][0], spans["reserve"][0], spans["pay"][0]
+
+		assert.Equal(t, 20*time.Millisecond, startOf(validate).Sub(startOf(checkout)))
+		assert.Equal(t, 10*time.Millisecond, startOf(reserve).Sub(endOf(validate)))
+		gap := startOf(pay).Sub(endOf(reserve))
+		assert.GreaterOrEqual(t, gap, 30*time.Millisecond)
+		assert.Less(t, gap, 40*time.Millisecond)
+	}
+}
+
+func TestTemplatedGenerator_DurationFromChildren(t *testing.T) {
+	query := &DurationDistribution{Type: DistributionFixed, Value: 300}
+	template := TraceTemplate{
+		Defaults: SpanDefaults{DurationFromChildren: true},
+		Spans: []SpanTemplate{
+			{Service: "shop-backend", Name: ptr("list-articles"), Duration: &DurationDistribution{Type: DistributionFixed, Value: 100}},
+			{Service: "shop-backend", Name: ptr("load-articles"), Timing: TimingSequential},
+			{Service: "postgres", Name: ptr("query-article"), Repeat: &Repeat{Min: 3, Max: 3}, Timing: TimingSequential, Duration: query},
+			{Service: "shop-backend", Name: ptr("render"), ParentIDX: ptr(0), Timing: TimingSequential, DurationFromChildren: ptr(false)},
+			{Service: "shop-backend", Name: ptr("render-article"), Duration: query},
+		},
+	}
+	gen, err := NewTemplatedGenerator(&template, random.NewSource(1))
+	require.NoError(t, err)
+
+	for range testRounds {
+		spans := spansByName(gen.Traces())
+		root, load, render := spans["list-articles"][0], spans["load-articles"][0], spans["render"][0]
+		queries := spans["query-article"]
+
+		// parents end after their children, even if their own duration is shorter
+		assert.Greater(t, endOf(load), endOf(queries[2]))
+		assert.Greater(t, endOf(root), endOf(render))
+		assert.Greater(t, endOf(root).Sub(startOf(root)), 900*time.Millisecond)
+		// the next sibling starts after the children of the previous sibling ended
+		assert.Equal(t, endOf(load), startOf(render))
+		// spans without durationFromChildren keep their duration
+		assert.Less(t, endOf(render), endOf(spans["render-article"][0]))
+	}
+}
+
+func TestTemplatedGenerator_TimingInvalid(t *testing.T) {
+	for name, span := range map[string]SpanTemplate{
+		"offset": {Offset: &DurationDistribution{Min: 10}},
+		"gap":    {Gap: &DurationDistribution{Type: DistributionNormal}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			span.Service = "shop-backend"
+			template := TraceTemplate{Spans: []SpanTemplate{{Service: "shop-backend"}, span}}
+			_, err := NewTemplatedGenerator(&template, nil)
+			assert.ErrorContains(t, err, "invalid span 1: invalid "+name)
+		})
+	}
+}
+
+func startOf(span ptrace.Span) time.Time {
+	return span.StartTimestamp().AsTime()
+}
+
+func endOf(span ptrace.Span) time.Time {
+	return span.EndTimestamp().AsTime()
+}
@@ -0,0 +1,396 @@
+package tracegen
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/grafana/xk6-client-tracing/pkg/random"
+)
+
+const (
+	defaultTopologyMaxDepth = 10
+	// maxTopologySpans limits the size of the trace templates created from a topology, since the number of spans
+	// can grow exponentially with the depth of the graph.
+	maxTopologySpans = 10000
+)
+
+// Topology describes a service graph. Traces are generated by walking the graph from one of its entry points.
+type Topology struct {
+	// Defaults parameters that are applied to each generated span.
+	Defaults SpanDefaults `js:"defaults"`
+	// Services the services of the graph and their operations.
+	Services []TopologyService `js:"services"`
+	// EntryPoints the operations at which traces start. If empty, each operation that isn't called by another
+	// operation is an entry point.
+	EntryPoints []TopologyEntryPoint `js:"entryPoints"`
+	// MaxDepth the maximum number of calls between the entry point and a span. Calls below are not followed, which
+	// limits the size of traces of graphs with cycles (default: 10).
+	MaxDepth int `js:"maxDepth"`
+}
+
+// TopologyService is a service of a Topology.
+type TopologyService struct {
+	// Name is used to set the service.name attribute of the resource.
+	Name string `js:"name"`
+	// AttributeSemantics the semantic convention of calls to the service, e.g. SemanticsDB for databases.
+	AttributeSemantics *OTelSemantics `js:"attributeSemantics"`
+	// Resource controls the attributes generated for the resource of the service.
+	Resource *ResourceTemplate `js:"resource"`
+	// Operations the operations provided by the service.
+	Operations []TopologyOperation `js:"operations"`
+}
+
+// TopologyOperation is an operation of a service, which may call operations of other services.
+type TopologyOperation struct {
+	// Name the name of the spans of the operation.
+	Name string `js:"name"`
+	// Duration the duration of the operation. The span of the operation ends after all of its calls ended, so this
+	// is the minimum duration of the operation.
+	Duration *DurationDistribution `js:"duration"`
+	// ErrorRate the probability between 0 and 1 that the operation fails.
+	ErrorRate *float32 `js:"errorRate"`
+	// Attributes that are added to the spans of the operation.
+	Attributes map[string]interface{} `js:"attributes"`
+	// Calls the operations called by this operation, which are the edges of the graph.
+	Calls []TopologyCall `js:"calls"`
+}
+
+// TopologyCall is a call of an operation, each call creates a client span in the calling service and a server span
+// in the called service. Calls within the same service create a single internal span.
+type TopologyCall struct {
+	// Service the name of the called service.
+	Service string `js:"service"`
+	// Operation the name of the called operation. If empty, the first operation of the service is called.
+	Operation string `js:"operation"`
+	// Probability the probability between 0 and 1 that the call is made (default: 1).
+	Probability *float32 `js:"probability"`
+	// Repeat how often the call is made.
+	Repeat *Repeat `js:"repeat"`
+	// Timing whether the call overlaps with other calls of the operation or is made after the previous sequential
+	// call ended.
+	Timing SiblingTiming `js:"timing"`
+	// Latency the time between the start of the client span and the start of the server span.
+	Latency *DurationDistribution `js:"latency"`
+	// ErrorRate the probability between 0 and 1 that the call fails, e.g. because of a network error.
+	ErrorRate *float32 `js:"errorRate"`
+}
+
+// TopologyEntryPoint is an operation at which traces start.
+type TopologyEntryPoint struct {
+	// Service the name of the service.
+	Service string `js:"service"`
+	// Operation the name of the operation. If empty, the first operation of the service is the entry point.
+	Operation string `js:"operation"`
+	// Weight the relative frequency of traces starting at this entry point (default: 1).
+	Weight float64 `js:"weight"`
+}
+
+// NewTopologyGenerator creates a new trace generator for a service graph. If rnd is nil, a randomly seeded Source
+// is used.
+func NewTopologyGenerator(topology *Topology, rnd *random.Source) (*TopologyGenerator, error) {
+	if rnd == nil {
+		rnd = random.NewRandomSource()
+	}
+	gen := &TopologyGenerator{rnd: rnd}
+	err := gen.initialize(topology)
+	if err != nil {
+		return nil, fmt.Errorf("fail to create new topology generator: %w", err)
+	}
+	return gen, nil
+}
+
+// TopologyGenerator a trace generator that creates traces by walking a service graph. Each entry point of the graph
+// is converted to a TraceTemplate, the templates share the resources of the services.
+type TopologyGenerator struct {
+	rnd        *random.Source
+	generators []*TemplatedGenerator
+	// weights the cumulative weights of the entry points
+	weights []float64
+}
+
+// Traces implements Generator for TopologyGenerator
+func (g *TopologyGenerator) Traces() ptrace.Traces {
+	if len(g.generators) == 1 {
+		return g.generators[0].Traces()
+	}
+	w := g.rnd.Float64() * g.weights[len(g.weights)-1]
+	i, found := slices.BinarySearch(g.weights, w)
+	if found {
+		i++
+	}
+	return g.generators[i].Traces()
+}
+
+func (g *TopologyGenerator) initialize(topology *Topology) error {
+	c, err := newTopologyCompiler(topology)
+	if err != nil {
+		return err
+	}
+
+	entryPoints := topology.EntryPoints
+	if len(entryPoints) == 0 {
+		entryPoints = c.entryPoints()
+		if len(entryPoints) == 0 {
+			return errors.New("topology has no entry points, all operations are called by other operations")
+		}
+	}
+
+	resources := map[string]*internalResourceTemplate{}
+	var total float64
+	for _, entryPoint := range entryPoints {
+		if entryPoint.Weight < 0 {
+			return fmt.Errorf("weight of entry point %s must not be negative", c.entryPointName(&entryPoint))
+		}
+		gen := &TemplatedGenerator{rnd: g.rnd, resources: resources}
+		template, err := c.template(&entryPoint)
+		if err == nil {
+			err = gen.initialize(template)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid entry point %s: %w", c.entryPointName(&entryPoint), err)
+		}
+
+		weight := entryPoint.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		total += weight
+		g.generators = append(g.generators, gen)
+		g.weights = append(g.weights, total)
+	}
+	return nil
+}
+
+// topologyCompiler converts the operations of a topology into trace templates.
+type topologyCompiler struct {
+	topology *Topology
+	services map[string]*TopologyService
+	maxDepth int
+	// resources contains the services whose resource was already added to a template
+	resources map[string]bool
+}
+
+func newTopologyCompiler(topology *Topology) (*topologyCompiler, error) {
+	c := &topologyCompiler{
+		topology:  topology,
+		services:  map[string]*TopologyService{},
+		maxDepth:  topology.MaxDepth,
+		resources: map[string]bool{},
+	}
+	if c.maxDepth < 0 {
+		return nil, errors.New("topology max depth must not be negative")
+	}
+	if c.maxDepth == 0 {
+		c.maxDepth = defaultTopologyMaxDepth
+	}
+	if len(topology.Services) == 0 {
+		return nil, errors.New("topology must have at least one service")
+	}
+
+	for i := range topology.Services {
+		service := &topology.Services[i]
+		if service.Name == "" {
+			return nil, fmt.Errorf("service %d of the topology must have a name", i)
+		}
+		if _, found := c.services[service.Name]; found {
+			return nil, fmt.Errorf("duplicate service %s", service.Name)
+		}
+		if len(service.Operations) == 0 {
+			return nil, fmt.Errorf("service %s must have at least one operation", service.Name)
+		}
+		c.services[service.Name] = service
+	}
+
+	// validate all operations and calls, also the ones that are not reachable from an entry point
+	for _, service := range topology.Services {
+		names := map[string]bool{}
+		for i, op := range service.Operations {
+			if op.Name == "" {
+				return nil, fmt.Errorf("operation %d of service %s must have a name", i, service.Name)
+			}
+			if names[op.Name] {
+				return nil, fmt.Errorf("duplicate operation %s of service %s", op.Name, service.Name)
+			}
+			names[op.Name] = true
+			if err := positiveDuration(op.Duration); err != nil {
+				return nil, fmt.Errorf("invalid duration of operation %s of service %s: %w", op.Name, service.Name, err)
+			}
+			for _, call := range op.Calls {
+				if _, _, err := c.operation(call.Service, call.Operation); err != nil {
+					return nil, fmt.Errorf("invalid call of operation %s of service %s: %w", op.Name, service.Name, err)
+				}
+				if err := positiveDuration(call.Latency); err != nil {
+					return nil, fmt.Errorf("invalid latency of call of operation %s of service %s: %w", op.Name, service.Name, err)
+				}
+			}
+		}
+	}
+	return c, nil
+}
+
+// positiveDuration validates a duration of the topology. Operations take time and calls have a latency, so fixed
+// durations must be positive.
+func positiveDuration(d *DurationDistribution) error {
+	if d == nil {
+		return nil
+	}
+	if _, err := newDurationSampler(d); err != nil {
+		return err
+	}
+	if d.Type == DistributionFixed && d.Value <= 0 {
+		return errors.New("fixed duration must be positive")
+	}
+	return nil
+}
+
+// operation returns a service and one of its operations, the first operation if name is empty.
+func (c *topologyCompiler) operation(serviceName, name string) (*TopologyService, *TopologyOperation, error) {
+	service, found := c.services[serviceName]
+	if !found {
+		return nil, nil, fmt.Errorf("unknown service %q", serviceName)
+	}
+	if name == "" {
+		return service, &service.Operations[0], nil
+	}
+	for i := range service.Operations {
+		if service.Operations[i].Name == name {
+			return service, &service.Operations[i], nil
+		}
+	}
+	return nil, nil, fmt.Errorf("unknown operation %q of service %s", name, serviceName)
+}
+
+// entryPointName returns the operation and service of an entry point for error messages.
+func (c *topologyCompiler) entryPointName(entryPoint *TopologyEntryPoint) string {
+	operation := entryPoint.Operation
+	if _, op, err := c.operation(entryPoint.Service, operation); err == nil {
+		operation = op.Name
+	}
+	if operation == "" {
+		return "of service " + entryPoint.Service
+	}
+	return fmt.Sprintf("%s of service %s", operation, entryPoint.Service)
+}
+
+// entryPoints returns all operations that aren't called by another operation.
+func (c *topologyCompiler) entryPoints() []TopologyEntryPoint {
+	called := map[*TopologyOperation]bool{}
+	for i := range c.topology.Services {
+		for j := range c.topology.Services[i].Operations {
+			op := &c.topology.Services[i].Operations[j]
+			for _, call := range op.Calls {
+				// recursive calls don't prevent an operation from being an entry point
+				if _, callee, _ := c.operation(call.Service, call.Operation); callee != op {
+					called[callee] = true
+				}
+			}
+		}
+	}
+
+	var entryPoints []TopologyEntryPoint
+	for _, service := range c.topology.Services {
+		for j := range service.Operations {
+			if !called[&service.Operations[j]] {
+				entryPoints = append(entryPoints, TopologyEntryPoint{Service: service.Name, Operation: service.Operations[j].Name})
+			}
+		}
+	}
+	return entryPoints
+}
+
+// template creates the trace template of an entry point.
+func (c *topologyCompiler) template(entryPoint *TopologyEntryPoint) (*TraceTemplate, error) {
+	service, op, err := c.operation(entryPoint.Service, entryPoint.Operation)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &TraceTemplate{Defaults: c.topology.Defaults}
+	template.Spans = append(template.Spans, c.operationSpan(service, op))
+	err = c.addCalls(template, 0, service, op, 1)
+	if err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// addCalls adds the spans of the calls of an operation and of the called operations to the template.
+func (c *topologyCompiler) addCalls(template *TraceTemplate, parentIdx int, service *TopologyService, op *TopologyOperation, depth int) error {
+	if depth > c.maxDepth {
+		return nil
+	}
+
+	for _, call := range op.Calls {
+		calleeService, callee, _ := c.operation(call.Service, call.Operation)
+		span := c.operationSpan(calleeService, callee)
+		spanParentIdx := parentIdx
+
+		if calleeService == service {
+			// calls within a service are internal spans, which fail because of the call or the operation
+			span.Probability, span.Repeat, span.Timing = call.Probability, call.Repeat, call.Timing
+			span.ErrorRate = combinedErrorRate(call.ErrorRate, callee.ErrorRate)
+		} else {
+			template.Spans = append(template.Spans, SpanTemplate{
+				Service:              service.Name,
+				Name:                 &callee.Name,
+				ParentIDX:            &parentIdx,
+				AttributeSemantics:   calleeService.AttributeSemantics,
+				Probability:          call.Probability,
+				Repeat:               call.Repeat,
+				Timing:               call.Timing,
+				ErrorRate:            call.ErrorRate,
+				DurationFromChildren: span.DurationFromChildren,
+			})
+			spanParentIdx = len(template.Spans) - 1
+			span.Offset = call.Latency
+		}
+
+		idx := len(template.Spans)
+		span.ParentIDX = &spanParentIdx
+		template.Spans = append(template.Spans, span)
+		if len(template.Spans) > maxTopologySpans {
+			return fmt.Errorf("traces have more than %d spans, reduce the max depth of the topology", maxTopologySpans)
+		}
+
+		err := c.addCalls(template, idx, calleeService, callee, depth+1)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// operationSpan returns the span template of an operation. The resource of the service is only added to the first
+// span of the service, since resource templates of spans of the same service are merged.
+func (c *topologyCompiler) operationSpan(service *TopologyService, op *TopologyOperation) SpanTemplate {
+	durationFromChildren := true
+	span := SpanTemplate{
+		Service:              service.Name,
+		Name:                 &op.Name,
+		Duration:             op.Duration,
+		AttributeSemantics:   service.AttributeSemantics,
+		Attributes:           op.Attributes,
+		ErrorRate:            op.ErrorRate,
+		DurationFromChildren: &durationFromChildren,
+	}
+	if !c.resources[service.Name] {
+		span.Resource = service.Resource
+		c.resources[service.Name] = true
+	}
+	return span
+}
+
+// combinedErrorRate returns the probability that at least one of two independent failures happens.
+func combinedErrorRate(a, b *float32) *float32 {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	}
+	rate := 1 - (1-*a)*(1-*b)
+	return &rate
+}
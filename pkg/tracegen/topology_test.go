@@ -0,0 +1,243 @@
+package tracegen
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/grafana/xk6-client-tracing/pkg/random"
+)
+
+func testTopology() Topology {
+	http, db := SemanticsHTTP, SemanticsDB
+	return Topology{
+		Defaults: SpanDefaults{AttributeSemantics: &http},
+		Services: []TopologyService{
+			{
+				Name: "frontend",
+				Operations: []TopologyOperation{
+					{Name: "GET /checkout", Calls: []TopologyCall{
+						{Service: "cart-service", Latency: &DurationDistribution{Type: DistributionFixed, Value: 2}, Timing: TimingSequential},
+						{Service: "frontend", Operation: "render", Timing: TimingSequential},
+					}},
+					{Name: "render", Duration: &DurationDistribution{Type: DistributionFixed, Value: 20}},
+					{Name: "GET /cart", Calls: []TopologyCall{{Service: "cart-service"}}},
+				},
+			},
+			{
+				Name:     "cart-service",
+				Resource: &ResourceTemplate{Attributes: map[string]interface{}{"namespace": "shop"}},
+				Operations: []TopologyOperation{
+					{Name: "get-cart", Duration: &DurationDistribution{Type: DistributionFixed, Value: 10}, Calls: []TopologyCall{
+						{Service: "postgres", Repeat: &Repeat{Min: 2, Max: 2}, Timing: TimingSequential},
+					}},
+				},
+			},
+			{
+				Name:               "postgres",
+				AttributeSemantics: &db,
+				Operations:         []TopologyOperation{{Name: "SELECT carts", Duration: &DurationDistribution{Type: DistributionFixed, Value: 50}}},
+			},
+		},
+	}
+}
+
+func TestTopologyGenerator_Traces(t *testing.T) {
+	topology := testTopology()
+	gen, err := NewTopologyGenerator(&topology, random.NewSource(1))
+	require.NoError(t, err)
+
+	hostIPs := map[string]string{}
+	entryPoints := map[string]bool{}
+	for range 20 {
+		traces := gen.Traces()
+		spans := collectSpansByKind(traces)
+		root := rootSpan(t, traces)
+		entryPoints[root.Name()] = true
+
+		// cart-service is called by a client span of the frontend
+		clients := spans["frontend/"+ptrace.SpanKindClient.String()]
+		require.Len(t, clients, 1)
+		assert.Equal(t, "get-cart", clients[0].Name())
+		servers := spans["cart-service/"+ptrace.SpanKindServer.String()]
+		require.Len(t, servers, 1)
+		assert.Equal(t, clients[0].SpanID(), servers[0].ParentSpanID())
+		assert.Len(t, spans["postgres/"+ptrace.SpanKindServer.String()], 2)
+
+		// operations end after their calls ended
+		assert.Greater(t, endOf(clients[0]), endOf(servers[0]))
+		assert.GreaterOrEqual(t, endOf(servers[0]).Sub(startOf(servers[0])), 100*time.Millisecond)
+		if root.Name() == "GET /checkout" {
+			assert.Equal(t, 2*time.Millisecond, startOf(servers[0]).Sub(startOf(clients[0])))
+			internal := spans["frontend/"+ptrace.SpanKindInternal.String()]
+			require.Len(t, internal, 1)
+			assert.Equal(t, "render", internal[0].Name())
+			assert.Equal(t, endOf(clients[0]), startOf(internal[0]))
+		}
+
+		// all traces share the resources of the services
+		for _, span := range servers {
+			ip, _ := span.Attributes().Get("net.sock.host.addr")
+			if prev, found := hostIPs["cart-service"]; found {
+				assert.Equal(t, prev, ip.Str())
+			}
+			hostIPs["cart-service"] = ip.Str()
+		}
+		for _, res := range iterResources(traces) {
+			if service, _ := res.Attributes().Get(attrServiceName); service.Str() == "cart-service" {
+				requireAttributeEqual(t, res.Attributes(), "namespace", "shop")
+			}
+		}
+	}
+	// operations that aren't called are entry points
+	assert.Equal(t, map[string]bool{"GET /checkout": true, "GET /cart": true}, entryPoints)
+}
+
+func TestTopologyGenerator_EntryPoints(t *testing.T) {
+	topology := testTopology()
+	topology.EntryPoints = []TopologyEntryPoint{
+		{Service: "frontend", Operation: "GET /checkout", Weight: 3},
+		{Service: "cart-service"},
+	}
+	gen, err := NewTopologyGenerator(&topology, random.NewSource(1))
+	require.NoError(t, err)
+
+	counts := map[string]int{}
+	for range 1000 {
+		counts[rootSpan(t, gen.Traces()).Name()]++
+	}
+	assert.InDelta(t, 750, counts["GET /checkout"], 50)
+	assert.InDelta(t, 250, counts["get-cart"], 50)
+}
+
+func TestTopologyGenerator_Cycles(t *testing.T) {
+	topology := Topology{
+		MaxDepth: 3,
+		Services: []TopologyService{
+			{Name: "ping", Operations: []TopologyOperation{{Name: "ping", Calls: []TopologyCall{{Service: "pong"}}}}},
+			{Name: "pong", Operations: []TopologyOperation{{Name: "pong", Calls: []TopologyCall{{Service: "ping"}}}}},
+		},
+		EntryPoints: []TopologyEntryPoint{{Service: "ping"}},
+	}
+	gen, err := NewTopologyGenerator(&topology, random.NewSource(1))
+	require.NoError(t, err)
+
+	// the root and a client and server span for each of the 3 calls
+	count := 0
+	for range iterSpans(gen.Traces()) {
+		count++
+	}
+	assert.Equal(t, 7, count)
+
+	// without entry points, a graph that only consists of cycles can't be walked
+	topology.EntryPoints = nil
+	_, err = NewTopologyGenerator(&topology, nil)
+	assert.ErrorContains(t, err, "topology has no entry points")
+}
+
+func TestTopologyGenerator_Errors(t *testing.T) {
+	topology := testTopology()
+	topology.Defaults.ErrorPropagation = ptr(float32(1))
+	topology.Services[2].Operations[0].ErrorRate = ptr(float32(1))
+	topology.EntryPoints = []TopologyEntryPoint{{Service: "frontend", Operation: "GET /cart"}}
+	gen, err := NewTopologyGenerator(&topology, random.NewSource(1))
+	require.NoError(t, err)
+
+	for _, span := range iterSpans(gen.Traces()) {
+		assert.Equal(t, ptrace.StatusCodeError, span.Status().Code(), span.Name())
+	}
+}
+
+func TestTopologyGenerator_Invalid(t *testing.T) {
+	tests := map[string]struct {
+		modify func(*Topology)
+		err    string
+	}{
+		"no services":       {func(tp *Topology) { tp.Services = nil }, "at least one service"},
+		"duplicate service": {func(tp *Topology) { tp.Services[1].Name = "frontend" }, "duplicate service frontend"},
+		"no operations":     {func(tp *Topology) { tp.Services[2].Operations = nil }, "service postgres must have at least one operation"},
+		"unnamed operation": {func(tp *Topology) { tp.Services[0].Operations[2].Name = "" }, "operation 2 of service frontend must have a name"},
+		"duplicate operation": {
+			func(tp *Topology) { tp.Services[0].Operations[2].Name = "render" },
+			"duplicate operation render of service frontend",
+		},
+		"unknown service": {
+			func(tp *Topology) { tp.Services[1].Operations[0].Calls[0].Service = "mysql" },
+			`invalid call of operation get-cart of service cart-service: unknown service "mysql"`,
+		},
+		"unknown operation": {
+			func(tp *Topology) { tp.Services[0].Operations[0].Calls[1].Operation = "paint" },
+			`unknown operation "paint" of service frontend`,
+		},
+		"unknown entry point": {
+			func(tp *Topology) { tp.EntryPoints = []TopologyEntryPoint{{Service: "backend"}} },
+			`invalid entry point of service backend: unknown service "backend"`,
+		},
+		"negative weight": {
+			func(tp *Topology) { tp.EntryPoints = []TopologyEntryPoint{{Service: "frontend", Weight: -1}} },
+			"weight of entry point GET /checkout of service frontend must not be negative",
+		},
+		"invalid call": {
+			func(tp *Topology) { tp.Services[0].Operations[0].Calls[0].Probability = ptr(float32(2)) },
+			"invalid entry point GET /checkout of service frontend: invalid span 1: probability must be between 0 and 1",
+		},
+		"zero duration": {
+			func(tp *Topology) { tp.Services[0].Operations[1].Duration.Value = 0 },
+			"invalid duration of operation render of service frontend: fixed duration must be positive",
+		},
+		"zero latency": {
+			func(tp *Topology) { tp.Services[0].Operations[0].Calls[0].Latency.Value = 0 },
+			"invalid latency of call of operation GET /checkout of service frontend: fixed duration must be positive",
+		},
+		"invalid latency": {
+			func(tp *Topology) { tp.Services[0].Operations[0].Calls[0].Latency = &DurationDistribution{Min: 5} },
+			"invalid latency of call of operation GET /checkout of service frontend: uniform duration max 0 must be greater than min 5",
+		},
+		"too many spans": {
+			func(tp *Topology) {
+				tp.MaxDepth = 20
+				tp.Services[1].Operations[0].Calls = []TopologyCall{{Service: "cart-service"}, {Service: "cart-service"}}
+			},
+			"traces have more than 10000 spans",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			topology := testTopology()
+			tt.modify(&topology)
+			_, err := NewTopologyGenerator(&topology, nil)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+// collectSpansByKind returns the spans of a trace by service and span kind.
+func collectSpansByKind(traces ptrace.Traces) map[string][]ptrace.Span {
+	spans := map[string][]ptrace.Span{}
+	for i := 0; i < traces.ResourceSpans().Len(); i++ {
+		rs := traces.ResourceSpans().At(i)
+		service, _ := rs.Resource().Attributes().Get(attrServiceName)
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			for k := 0; k < rs.ScopeSpans().At(j).Spans().Len(); k++ {
+				span := rs.ScopeSpans().At(j).Spans().At(k)
+				key := service.Str() + "/" + span.Kind().String()
+				spans[key] = append(spans[key], span)
+			}
+		}
+	}
+	return spans
+}
+
+func rootSpan(t *testing.T, traces ptrace.Traces) ptrace.Span {
+	t.Helper()
+	for _, span := range iterSpans(traces) {
+		if span.ParentSpanID().IsEmpty() {
+			return span
+		}
+	}
+	require.Fail(t, "trace has no root span")
+	return ptrace.Span{}
+}
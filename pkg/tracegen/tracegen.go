@@ -17,6 +17,27 @@ const (
 	attrURL                             = "url.full"
 	attrURLScheme                       = "url.schema"
 	attrURLTarget                       = "url.target"
+	attrServerAddress                   = "server.address"
+	attrServerPort                      = "server.port"
+	attrErrorType                       = "error.type"
+	attrDBSystem                        = "db.system"
+	attrDBNamespace                     = "db.namespace"
+	attrDBCollectionName                = "db.collection.name"
+	attrDBOperationName                 = "db.operation.name"
+	attrDBQueryText                     = "db.query.text"
+	attrDBResponseStatusCode            = "db.response.status_code"
+	attrDBResponseReturnedRows          = "db.response.returned_rows"
+	attrRPCSystem                       = "rpc.system"
+	attrRPCService                      = "rpc.service"
+	attrRPCMethod                       = "rpc.method"
+	attrRPCGRPCStatusCode               = "rpc.grpc.status_code"
+	attrMessagingSystem                 = "messaging.system"
+	attrMessagingDestinationName        = "messaging.destination.name"
+	attrMessagingDestinationPartitionID = "messaging.destination.partition.id"
+	attrMessagingOperationType          = "messaging.operation.type"
+	attrMessagingMessageID              = "messaging.message.id"
+	attrMessagingBatchMessageCount      = "messaging.batch.message_count"
+	attrMessagingConsumerGroupName      = "messaging.consumer.group.name"
 )
 
 // Generator creates traces to be used in k6 tests
@@ -0,0 +1,200 @@
+package tracegen
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/grafana/xk6-client-tracing/pkg/random"
+)
+
+// maxTraceQLConditions the maximum number of conditions that are combined with the service name of a span.
+const maxTraceQLConditions = 2
+
+// traceQLIdentifier matches attribute names that can be used in TraceQL without quotes.
+var traceQLIdentifier = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_.]*$`)
+
+// TraceQLGenerator creates TraceQL queries that match the spans of the traces created by a TemplatedGenerator.
+type TraceQLGenerator struct {
+	rnd   *random.Source
+	spans []traceQLSpan
+}
+
+// traceQLSpan contains the conditions that are true for all spans created from a span template.
+type traceQLSpan struct {
+	service    string
+	conditions []traceQLCondition
+}
+
+// traceQLCondition compares a span property with a value, which is picked randomly from the TraceQL literals in
+// values for each query.
+type traceQLCondition struct {
+	property string
+	operator string
+	values   []string
+}
+
+// NewTraceQLGenerator creates a generator for TraceQL queries from the spans, services and attributes of the
+// template of gen. If rnd is nil, a randomly seeded Source is used.
+func NewTraceQLGenerator(gen *TemplatedGenerator, rnd *random.Source) *TraceQLGenerator {
+	if rnd == nil {
+		rnd = random.NewRandomSource()
+	}
+
+	g := &TraceQLGenerator{rnd: rnd}
+	failing := failingSpans(gen)
+	for _, tmpl := range gen.spans {
+		span := traceQLSpan{service: tmpl.resource.service}
+		span.add("name", "=", tmpl.name)
+		if kind := traceQLKind(tmpl.kind); kind != "" {
+			span.conditions = append(span.conditions, traceQLCondition{property: "kind", operator: "=", values: []string{kind}})
+		}
+
+		for _, k := range slices.Sorted(maps.Keys(tmpl.attributes)) {
+			span.add(traceQLAttribute("span", k), "=", tmpl.attributes[k])
+		}
+		for _, k := range slices.Sorted(maps.Keys(tmpl.randomAttributes)) {
+			span.add(traceQLAttribute("span", k), "=", tmpl.randomAttributes[k]...)
+		}
+		for _, k := range slices.Sorted(maps.Keys(tmpl.resource.attributes)) {
+			span.add(traceQLAttribute("resource", k), "=", tmpl.resource.attributes[k])
+		}
+		for _, k := range slices.Sorted(maps.Keys(gen.randomAttributes)) {
+			span.add(traceQLAttribute("span", k), "=", gen.randomAttributes[k]...)
+		}
+
+		// attributes of semantic conventions are only used if the template doesn't override them
+		semantic := func(k, op string, values ...interface{}) {
+			if _, found := tmpl.attributes[k]; !found {
+				span.add(traceQLAttribute("span", k), op, values...)
+			}
+		}
+		// the status code is only set if the template doesn't use the old attribute, and is only an error if the
+		// span can fail
+		_, oldStatus := tmpl.attributes[attrHTTPStatusCodeOld]
+		if tmpl.attributeSemantics != nil && *tmpl.attributeSemantics == SemanticsHTTP && tmpl.kind == ptrace.SpanKindServer && !oldStatus {
+			if failing[tmpl] {
+				semantic(attrHTTPStatusCode, ">=", 200, 400, 500)
+			} else {
+				semantic(attrHTTPStatusCode, ">=", 200)
+			}
+		}
+		if tmpl.db != nil && tmpl.kind != ptrace.SpanKindInternal {
+			semantic(attrDBSystem, "=", tmpl.db.system)
+			semantic(attrDBCollectionName, "=", tmpl.db.collection)
+		}
+		if tmpl.messaging != nil && tmpl.kind != ptrace.SpanKindInternal {
+			semantic(attrMessagingSystem, "=", tmpl.messaging.system)
+			semantic(attrMessagingDestinationName, "=", tmpl.messaging.destination)
+		}
+		if tmpl.rpc != nil && tmpl.kind != ptrace.SpanKindInternal {
+			semantic(attrRPCService, "=", tmpl.rpc.service)
+			semantic(attrRPCMethod, "=", tmpl.rpc.method)
+		}
+
+		g.spans = append(g.spans, span)
+	}
+	return g
+}
+
+// failingSpans returns the span templates that can fail, because of their own error rate, the error rate of the
+// client span that calls them or failures that are propagated from their children.
+func failingSpans(gen *TemplatedGenerator) map[*internalSpanTemplate]bool {
+	failing := map[*internalSpanTemplate]bool{}
+	for _, tmpl := range gen.spans {
+		if tmpl.errorRate > 0 {
+			failing[tmpl] = true
+			if tmpl.callee != nil {
+				failing[tmpl.callee] = true
+			}
+		}
+	}
+	// children are defined after their parents, so failures are propagated up the parent chain in reverse order
+	for i := len(gen.spans) - 1; i >= 0; i-- {
+		tmpl := gen.spans[i]
+		parent := tmpl.parent
+		if !failing[tmpl] || parent == nil || tmpl.kind == ptrace.SpanKindConsumer {
+			continue
+		}
+		if parent.callee == tmpl || parent.errorPropagation > 0 {
+			failing[parent] = true
+		}
+	}
+	return failing
+}
+
+// Query returns a query that matches a random span of the template. The query selects the service of the span
+// and up to two further conditions, e.g.
+//
+//	{ resource.service.name = "shop-backend" && span.http.response.status_code >= 500 }
+func (g *TraceQLGenerator) Query() string {
+	if len(g.spans) == 0 {
+		return "{}"
+	}
+	span := random.Pick(g.rnd, g.spans)
+
+	conditions := []string{traceQLAttribute("resource", attrServiceName) + " = " + strconv.Quote(span.service)}
+	candidates := slices.Clone(span.conditions)
+	for n := min(g.rnd.IntN(maxTraceQLConditions+1), len(candidates)); n > 0; n-- {
+		i := g.rnd.IntN(len(candidates))
+		c := candidates[i]
+		candidates = slices.Delete(candidates, i, i+1)
+		conditions = append(conditions, fmt.Sprintf("%s %s %s", c.property, c.operator, random.Pick(g.rnd, c.values)))
+	}
+	return "{ " + strings.Join(conditions, " && ") + " }"
+}
+
+// add adds a condition if all values can be expressed in TraceQL.
+func (s *traceQLSpan) add(property, operator string, values ...interface{}) {
+	literals := make([]string, 0, len(values))
+	for _, v := range values {
+		literal := traceQLValue(v)
+		if literal == "" {
+			return
+		}
+		literals = append(literals, literal)
+	}
+	if len(literals) > 0 {
+		s.conditions = append(s.conditions, traceQLCondition{property: property, operator: operator, values: literals})
+	}
+}
+
+// traceQLAttribute returns the scoped attribute name, names with special characters are quoted.
+func traceQLAttribute(scope, name string) string {
+	if !traceQLIdentifier.MatchString(name) {
+		name = strconv.Quote(name)
+	}
+	return scope + "." + name
+}
+
+// traceQLValue returns the TraceQL literal of an attribute value or an empty string for unsupported values.
+func traceQLValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+func traceQLKind(kind ptrace.SpanKind) string {
+	switch kind {
+	case ptrace.SpanKindServer, ptrace.SpanKindClient, ptrace.SpanKindProducer, ptrace.SpanKindConsumer, ptrace.SpanKindInternal:
+		return strings.ToLower(kind.String())
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,170 @@
+package tracegen
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/grafana/xk6-client-tracing/pkg/random"
+)
+
+func TestTraceQLGenerator_Query(t *testing.T) {
+	semantics := SemanticsHTTP
+	template := TraceTemplate{
+		Spans: []SpanTemplate{
+			{Service: "shop-backend", Name: ptr("list-articles"), Attributes: map[string]interface{}{
+				"fixed.attr": "some-value",
+				"count":      int64(3),
+				"odd key":    true,
+				"object":     map[string]interface{}{"a": "b"},
+			}},
+			{Service: "article-service", Name: ptr("get-article"), AttributeSemantics: &semantics, ErrorRate: ptr(float32(0.1)), Resource: &ResourceTemplate{Attributes: map[string]interface{}{"k8s.pod.name": "article-1"}}},
+		},
+	}
+	gen, err := NewTemplatedGenerator(&template, random.NewSource(1))
+	require.NoError(t, err)
+	qgen := NewTraceQLGenerator(gen, random.NewSource(1))
+
+	conditions := map[string]bool{}
+	for range 500 {
+		query := qgen.Query()
+		require.True(t, strings.HasPrefix(query, "{ ") && strings.HasSuffix(query, " }"), query)
+		parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(query, "{ "), " }"), " && ")
+		require.LessOrEqual(t, len(parts), 1+maxTraceQLConditions)
+		assert.Contains(t, []string{`resource.service.name = "shop-backend"`, `resource.service.name = "article-service"`}, parts[0])
+		for _, c := range parts[1:] {
+			conditions[c] = true
+		}
+	}
+
+	for _, expected := range []string{
+		`name = "list-articles"`,
+		`kind = server`,
+		`kind = client`,
+		`span.fixed.attr = "some-value"`,
+		`span.count = 3`,
+		`span."odd key" = true`,
+		`span.http.response.status_code >= 500`,
+		`resource.k8s.pod.name = "article-1"`,
+	} {
+		assert.Contains(t, conditions, expected)
+	}
+	for c := range conditions {
+		assert.NotContains(t, c, "object")
+	}
+}
+
+func TestTraceQLGenerator_Matches(t *testing.T) {
+	semantics := SemanticsDB
+	template := TraceTemplate{
+		Defaults: SpanDefaults{Attributes: map[string]interface{}{"env": "test"}},
+		Spans: []SpanTemplate{
+			{Service: "shop-backend", Name: ptr("checkout")},
+			{Service: "shop-backend", Name: ptr("load-cart"), Attributes: map[string]interface{}{"cart.items": int64(4)}},
+			{Service: "postgres", AttributeSemantics: &semantics},
+		},
+	}
+	gen, err := NewTemplatedGenerator(&template, nil)
+	require.NoError(t, err)
+	qgen := NewTraceQLGenerator(gen, nil)
+
+	for range 100 {
+		query := qgen.Query()
+		assert.True(t, traceMatches(gen.Traces(), query), query)
+	}
+}
+
+func TestTraceQLGenerator_HTTPStatus(t *testing.T) {
+	semantics := SemanticsHTTP
+	template := TraceTemplate{
+		Spans: []SpanTemplate{
+			{Service: "shop-backend", Name: ptr("checkout"), AttributeSemantics: &semantics},
+			{Service: "shop-backend", Name: ptr("pay"), AttributeSemantics: &semantics},
+			{Service: "payment-service", Name: ptr("pay"), AttributeSemantics: &semantics, ErrorRate: ptr(float32(1))},
+		},
+	}
+	gen, err := NewTemplatedGenerator(&template, nil)
+	require.NoError(t, err)
+	qgen := NewTraceQLGenerator(gen, nil)
+
+	errorQueries := 0
+	for range 200 {
+		query := qgen.Query()
+		if strings.Contains(query, "status_code >= 400") || strings.Contains(query, "status_code >= 500") {
+			errorQueries++
+			// the checkout span doesn't fail, because it doesn't propagate the errors of its children
+			assert.Contains(t, query, "payment-service")
+		}
+		assert.True(t, traceMatches(gen.Traces(), query), query)
+	}
+	assert.Positive(t, errorQueries)
+}
+
+// traceMatches evaluates queries of the TraceQLGenerator that only contain equality conditions and lower bounds
+// of integers.
+func traceMatches(traces ptrace.Traces, query string) bool {
+	conditions := strings.Split(strings.TrimSuffix(strings.TrimPrefix(query, "{ "), " }"), " && ")
+	for _, span := range iterSpans(traces) {
+		resource := spanResource(traces, span)
+		matches := true
+		for _, c := range conditions {
+			property, literal, atLeast := strings.Cut(c, " >= ")
+			if !atLeast {
+				property, literal, _ = strings.Cut(c, " = ")
+			}
+			var actual string
+			switch {
+			case property == "name":
+				actual = strconv.Quote(span.Name())
+			case property == "kind":
+				actual = strings.ToLower(span.Kind().String())
+			case strings.HasPrefix(property, "span."):
+				actual = attributeLiteral(span.Attributes(), strings.TrimPrefix(property, "span."))
+			case strings.HasPrefix(property, "resource."):
+				actual = attributeLiteral(resource.Attributes(), strings.TrimPrefix(property, "resource."))
+			}
+			if atLeast {
+				actualInt, err := strconv.Atoi(actual)
+				minInt, _ := strconv.Atoi(literal)
+				matches = matches && err == nil && actualInt >= minInt
+			} else {
+				matches = matches && actual == literal
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+func attributeLiteral(attrs pcommon.Map, name string) string {
+	if unquoted, err := strconv.Unquote(name); err == nil {
+		name = unquoted
+	}
+	v, found := attrs.Get(name)
+	if !found {
+		return ""
+	}
+	return traceQLValue(v.AsRaw())
+}
+
+func spanResource(traces ptrace.Traces, span ptrace.Span) pcommon.Resource {
+	for i := 0; i < traces.ResourceSpans().Len(); i++ {
+		rs := traces.ResourceSpans().At(i)
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			spans := rs.ScopeSpans().At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				if spans.At(k).SpanID() == span.SpanID() {
+					return rs.Resource()
+				}
+			}
+		}
+	}
+	return pcommon.NewResource()
+}
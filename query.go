@@ -0,0 +1,451 @@
+package clienttracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.k6.io/k6/v2/js/modules"
+	"go.k6.io/k6/v2/metrics"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"golang.org/x/oauth2"
+)
+
+type backendType string
+
+const (
+	backendTempo  backendType = "tempo"
+	backendJaeger backendType = "jaeger"
+)
+
+const (
+	defaultQueryTimeout   = 10 * time.Second
+	defaultVerifyTimeout  = 30 * time.Second
+	defaultVerifyInterval = time.Second
+)
+
+var errTraceNotFound = errors.New("trace not found")
+
+// QueryConfig configures a client for the query API of a tracing backend.
+type QueryConfig struct {
+	// Backend the type of the backend: "tempo" or "jaeger" (default: "tempo")
+	Backend backendType `js:"backend"`
+	// URL the base URL of the query API, e.g. "http://tempo:3200"
+	URL string `js:"url"`
+	// TLS configuration of https connections (optional)
+	TLS TLSClientConfig `js:"tls"`
+	// Authentication configures the credentials sent with every query (optional)
+	Authentication AuthenticationConfig `js:"authentication"`
+	// Headers additional headers sent with every query, e.g. the tenant (optional)
+	Headers map[string]string `js:"headers"`
+	// Timeout of a single query (default: "10s")
+	Timeout string `js:"timeout"`
+}
+
+// VerifyOptions contains the options that can be passed as second argument to verify.
+type VerifyOptions struct {
+	// Timeout how long to wait until the traces are queryable (default: "30s")
+	Timeout string `js:"timeout"`
+	// Interval the time between queries for traces that were not found yet (default: "1s")
+	Interval string `js:"interval"`
+}
+
+// SearchOptions contains the options that can be passed as second argument to search.
+type SearchOptions struct {
+	// Limit the maximum number of traces returned (default: the limit of the backend)
+	Limit int `js:"limit"`
+	// Since restricts the search to traces of the given time range before now, e.g. "15m" (optional)
+	Since string `js:"since"`
+}
+
+// SearchResult is the outcome of a TraceQL search.
+type SearchResult struct {
+	// Traces the number of traces that matched the query
+	Traces int `js:"traces"`
+	// Spans the number of spans that matched the query
+	Spans int `js:"spans"`
+	// TraceIDs the ids of the traces that matched the query
+	TraceIDs []string `js:"trace_ids"`
+}
+
+// VerifyResult is the outcome of comparing pushed traces with the traces returned by the query API.
+type VerifyResult struct {
+	// Traces the number of verified traces
+	Traces int `js:"traces"`
+	// Found the number of traces that became queryable before the timeout
+	Found int `js:"found"`
+	// NotFound the ids of the traces that did not become queryable before the timeout
+	NotFound []string `js:"not_found"`
+	// Mismatches describes the differences between the pushed and the queried traces
+	Mismatches []string `js:"mismatches"`
+	// OK whether all traces were found without mismatches
+	OK bool `js:"ok"`
+}
+
+// QueryClient reads traces from the query API of Tempo or Jaeger and runs TraceQL searches against Tempo.
+type QueryClient struct {
+	backend backendType
+	url     string
+	headers http.Header
+	client  *http.Client
+	vu      modules.VU
+	metrics *tracingMetrics
+}
+
+// NewQueryClient creates a client for the query API of a tracing backend. If m is not nil, a set of k6 samples is
+// emitted for every verified trace and every search.
+func NewQueryClient(cfg *QueryConfig, vu modules.VU, m *tracingMetrics) (*QueryClient, error) {
+	backend := cfg.Backend
+	switch backend {
+	case "":
+		backend = backendTempo
+	case backendTempo, backendJaeger:
+	default:
+		return nil, fmt.Errorf("unknown backend type %s", backend)
+	}
+
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid url %q: expected http(s)://<host>:<port>", cfg.URL)
+	}
+
+	timeout := defaultQueryTimeout
+	if err := parseDuration(cfg.Timeout, &timeout); err != nil {
+		return nil, fmt.Errorf("invalid timeout: %w", err)
+	}
+
+	tlsConfig, err := cfg.TLS.clientConfig().LoadTLSConfig(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("invalid tls config: %w", err)
+	}
+
+	auth, err := newClientAuth(&cfg.Authentication)
+	if err != nil {
+		return nil, fmt.Errorf("invalid authentication: %w", err)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	var roundTripper http.RoundTripper = transport
+	if auth.source != nil {
+		roundTripper = &oauth2.Transport{Source: auth.source, Base: roundTripper}
+	}
+
+	headers := make(http.Header, len(cfg.Headers)+1)
+	for name, value := range cfg.Headers {
+		headers.Set(name, value)
+	}
+	if auth.header != "" {
+		headers.Set("Authorization", string(auth.header))
+	}
+
+	return &QueryClient{
+		backend: backend,
+		url:     strings.TrimSuffix(cfg.URL, "/"),
+		headers: headers,
+		client:  &http.Client{Transport: roundTripper, Timeout: timeout},
+		vu:      vu,
+		metrics: m,
+	}, nil
+}
+
+// GetTrace returns the trace with the given hex encoded id.
+func (c *QueryClient) GetTrace(traceID string) (*Traces, error) {
+	id, err := parseTraceID(traceID)
+	if err != nil {
+		return nil, err
+	}
+	trace, err := c.fetchTrace(c.vu.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+	return newTraces(trace), nil
+}
+
+// Verify queries every trace in traces until it is found or the timeout expires and compares it with the pushed
+// trace. The time until a trace was found is recorded as time to queryable, so Verify should be called right
+// after the traces were pushed.
+func (c *QueryClient) Verify(traces *Traces, opts *VerifyOptions) (*VerifyResult, error) {
+	timeout, interval := defaultVerifyTimeout, defaultVerifyInterval
+	if opts != nil {
+		if err := parseDuration(opts.Timeout, &timeout); err != nil {
+			return nil, fmt.Errorf("invalid timeout: %w", err)
+		}
+		if err := parseDuration(opts.Interval, &interval); err != nil {
+			return nil, fmt.Errorf("invalid interval: %w", err)
+		}
+	}
+
+	ctx := c.vu.Context()
+	tags := c.metricTags()
+	start := time.Now()
+	deadline := start.Add(timeout)
+
+	pending := splitTraces(traces.traces)
+	result := &VerifyResult{Traces: len(pending), NotFound: []string{}, Mismatches: []string{}}
+	lastErrs := make(map[pcommon.TraceID]error)
+	for {
+		var next []*traceSpans
+		for _, expected := range pending {
+			actual, err := c.fetchTrace(ctx, expected.id)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				if !errors.Is(err, errTraceNotFound) {
+					lastErrs[expected.id] = err
+				}
+				next = append(next, expected)
+				continue
+			}
+
+			mismatches := compareTrace(expected, actual)
+			result.Found++
+			result.Mismatches = append(result.Mismatches, mismatches...)
+			c.emitVerifyMetrics(verifyStats{
+				start:           start,
+				found:           true,
+				timeToQueryable: time.Since(start),
+				mismatches:      len(mismatches),
+			}, tags)
+		}
+
+		pending = next
+		if len(pending) == 0 || !time.Now().Before(deadline) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(min(interval, time.Until(deadline))):
+		}
+	}
+
+	for _, expected := range pending {
+		id := expected.id.String()
+		if err, ok := lastErrs[expected.id]; ok {
+			id += " (" + err.Error() + ")"
+		}
+		result.NotFound = append(result.NotFound, id)
+		c.emitVerifyMetrics(verifyStats{start: start}, tags)
+	}
+	result.OK = len(result.NotFound) == 0 && len(result.Mismatches) == 0
+	return result, nil
+}
+
+// Search runs a TraceQL query against the Tempo search API. The duration and the number of hits are recorded as
+// k6 metrics.
+func (c *QueryClient) Search(query string, opts *SearchOptions) (*SearchResult, error) {
+	if c.backend != backendTempo {
+		return nil, fmt.Errorf("TraceQL search is not supported by %s", c.backend)
+	}
+
+	params := url.Values{"q": {query}}
+	if opts != nil {
+		if opts.Limit > 0 {
+			params.Set("limit", strconv.Itoa(opts.Limit))
+		}
+		var since time.Duration
+		if err := parseDuration(opts.Since, &since); err != nil {
+			return nil, fmt.Errorf("invalid since: %w", err)
+		}
+		if since > 0 {
+			now := time.Now()
+			params.Set("start", strconv.FormatInt(now.Add(-since).Unix(), 10))
+			params.Set("end", strconv.FormatInt(now.Unix(), 10))
+		}
+	}
+
+	tags := c.metricTags()
+	stats := searchStats{start: time.Now()}
+	result, err := c.search(c.vu.Context(), params)
+	stats.duration = time.Since(stats.start)
+	stats.err = err
+	if result != nil {
+		stats.hits = result.Traces
+	}
+	c.emitSearchMetrics(stats, tags)
+	return result, err
+}
+
+// tempoSearchResponse contains the parts of the response of the Tempo search API that are used by Search.
+type tempoSearchResponse struct {
+	Traces []struct {
+		TraceID  string         `json:"traceID"`
+		SpanSet  *tempoSpanSet  `json:"spanSet"`
+		SpanSets []tempoSpanSet `json:"spanSets"`
+	} `json:"traces"`
+}
+
+type tempoSpanSet struct {
+	Matched int `json:"matched"`
+}
+
+func (c *QueryClient) search(ctx context.Context, params url.Values) (*SearchResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+"/api/search?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = c.headers.Clone()
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("search failed with status %s", resp.Status)
+	}
+
+	var body tempoSearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid search response: %w", err)
+	}
+
+	result := &SearchResult{Traces: len(body.Traces), TraceIDs: make([]string, 0, len(body.Traces))}
+	for _, trace := range body.Traces {
+		result.TraceIDs = append(result.TraceIDs, trace.TraceID)
+		spanSets := trace.SpanSets
+		if len(spanSets) == 0 && trace.SpanSet != nil {
+			spanSets = []tempoSpanSet{*trace.SpanSet}
+		}
+		for _, spanSet := range spanSets {
+			result.Spans += spanSet.Matched
+		}
+	}
+	return result, nil
+}
+
+// fetchTrace requests a single trace from the query API, errTraceNotFound is returned if the trace doesn't exist.
+func (c *QueryClient) fetchTrace(ctx context.Context, id pcommon.TraceID) (ptrace.Traces, error) {
+	path := "/api/traces/"
+	if c.backend == backendJaeger {
+		path = "/api/v3/traces/"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+path+id.String(), nil)
+	if err != nil {
+		return ptrace.Traces{}, err
+	}
+	req.Header = c.headers.Clone()
+	if c.backend == backendTempo {
+		// the protobuf representation of Tempo traces is compatible with OTLP
+		req.Header.Set("Accept", "application/protobuf")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return ptrace.Traces{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ptrace.Traces{}, errTraceNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return ptrace.Traces{}, fmt.Errorf("query failed with status %s", resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ptrace.Traces{}, err
+	}
+
+	var traces ptrace.Traces
+	if c.backend == backendJaeger {
+		traces, err = unmarshalJaegerTraces(body)
+	} else {
+		traces, err = (&ptrace.ProtoUnmarshaler{}).UnmarshalTraces(body)
+	}
+	if err != nil {
+		return ptrace.Traces{}, fmt.Errorf("invalid query response: %w", err)
+	}
+	if traces.SpanCount() == 0 {
+		return ptrace.Traces{}, errTraceNotFound
+	}
+	return traces, nil
+}
+
+// jaegerMessage is a message of the HTTP gateway of the Jaeger query API v3, which streams a trace as a sequence
+// of OTLP JSON chunks.
+type jaegerMessage struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		HTTPCode int    `json:"httpCode"`
+		Message  string `json:"message"`
+	} `json:"error"`
+}
+
+func unmarshalJaegerTraces(body []byte) (ptrace.Traces, error) {
+	traces := ptrace.NewTraces()
+	dec := json.NewDecoder(bytes.NewReader(body))
+	for {
+		var msg jaegerMessage
+		if err := dec.Decode(&msg); errors.Is(err, io.EOF) {
+			return traces, nil
+		} else if err != nil {
+			return ptrace.Traces{}, err
+		}
+		if msg.Error != nil {
+			if msg.Error.HTTPCode == http.StatusNotFound {
+				return ptrace.Traces{}, errTraceNotFound
+			}
+			return ptrace.Traces{}, errors.New(msg.Error.Message)
+		}
+		if len(msg.Result) == 0 {
+			continue
+		}
+		chunk, err := (&ptrace.JSONUnmarshaler{}).UnmarshalTraces(msg.Result)
+		if err != nil {
+			return ptrace.Traces{}, err
+		}
+		chunk.ResourceSpans().MoveAndAppendTo(traces.ResourceSpans())
+	}
+}
+
+func parseTraceID(s string) (pcommon.TraceID, error) {
+	var id pcommon.TraceID
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != len(id) {
+		return id, fmt.Errorf("invalid trace id %q: expected %d hex encoded bytes", s, len(id))
+	}
+	copy(id[:], b)
+	return id, nil
+}
+
+// metricTags returns the tags for the samples of a verification, nil is returned outside a VU context.
+func (c *QueryClient) metricTags() *metrics.TagsAndMeta {
+	state := c.vu.State()
+	if c.metrics == nil || state == nil {
+		return nil
+	}
+
+	tags := state.Tags.GetCurrentValues()
+	tags.SetTag(tagBackend, string(c.backend))
+	tags.SetTag(tagEndpoint, c.url)
+	return &tags
+}
+
+// emitVerifyMetrics sends the samples for a verified trace to k6.
+func (c *QueryClient) emitVerifyMetrics(stats verifyStats, tags *metrics.TagsAndMeta) {
+	if tags == nil {
+		return
+	}
+	metrics.PushIfNotDone(c.vu.Context(), c.vu.State().Samples, c.metrics.verifySamples(stats, tags))
+}
+
+// emitSearchMetrics sends the samples for a search to k6.
+func (c *QueryClient) emitSearchMetrics(stats searchStats, tags *metrics.TagsAndMeta) {
+	if tags == nil {
+		return
+	}
+	metrics.PushIfNotDone(c.vu.Context(), c.vu.State().Samples, c.metrics.searchSamples(stats, tags))
+}
@@ -0,0 +1,279 @@
+package clienttracing
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/v2/js/modulestest"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/grafana/xk6-client-tracing/pkg/tracegen"
+)
+
+// queryBackend is a stub of the Tempo and Jaeger query APIs. Traces become queryable after a number of
+// requests to simulate the ingestion delay of a backend.
+type queryBackend struct {
+	backend backendType
+	delay   int
+
+	mu       sync.Mutex
+	traces   map[string]ptrace.Traces
+	requests map[string]int
+	queries  []string
+}
+
+func newQueryBackend(t *testing.T, backend backendType, traces ptrace.Traces) (*queryBackend, *httptest.Server) {
+	t.Helper()
+
+	b := &queryBackend{backend: backend, traces: map[string]ptrace.Traces{}, requests: map[string]int{}}
+	for _, ts := range splitTraces(traces) {
+		trace := ptrace.NewTraces()
+		traces.CopyTo(trace)
+		trace.ResourceSpans().RemoveIf(func(rs ptrace.ResourceSpans) bool {
+			rs.ScopeSpans().RemoveIf(func(ss ptrace.ScopeSpans) bool {
+				ss.Spans().RemoveIf(func(span ptrace.Span) bool { return span.TraceID() != ts.id })
+				return ss.Spans().Len() == 0
+			})
+			return rs.ScopeSpans().Len() == 0
+		})
+		b.traces[ts.id.String()] = trace
+	}
+
+	srv := httptest.NewServer(b)
+	t.Cleanup(srv.Close)
+	return b, srv
+}
+
+func (b *queryBackend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api/search" {
+		b.search(w, r)
+		return
+	}
+
+	prefix := "/api/traces/"
+	if b.backend == backendJaeger {
+		prefix = "/api/v3/traces/"
+	}
+	id, ok := strings.CutPrefix(r.URL.Path, prefix)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	b.mu.Lock()
+	b.requests[id]++
+	trace, found := b.traces[id]
+	found = found && b.requests[id] > b.delay
+	b.mu.Unlock()
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if b.backend == backendJaeger {
+		data, _ := (&ptrace.JSONMarshaler{}).MarshalTraces(trace)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"result":%s}`, data)
+		return
+	}
+	data, _ := (&ptrace.ProtoMarshaler{}).MarshalTraces(trace)
+	w.Header().Set("Content-Type", "application/protobuf")
+	_, _ = w.Write(data)
+}
+
+// search returns all traces with one matching span.
+func (b *queryBackend) search(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.queries = append(b.queries, r.URL.Query().Get("q"))
+
+	var traces []string
+	for id := range b.traces {
+		traces = append(traces, fmt.Sprintf(`{"traceID":%q,"spanSets":[{"matched":1}]}`, id))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = fmt.Fprintf(w, `{"traces":[%s],"metrics":{"inspectedTraces":%d}}`, strings.Join(traces, ","), len(traces))
+}
+
+// modify changes the stored copy of every trace.
+func (b *queryBackend) modify(fn func(ptrace.Traces)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, trace := range b.traces {
+		fn(trace)
+	}
+}
+
+func TestQueryClient_Verify(t *testing.T) {
+	traces := tracegen.NewParameterizedGenerator([]*tracegen.TraceParams{
+		{Count: 2, Spans: tracegen.SpanParams{Count: 3}},
+	}, nil).Traces()
+
+	for _, backend := range []backendType{backendTempo, backendJaeger} {
+		t.Run(string(backend), func(t *testing.T) {
+			b, srv := newQueryBackend(t, backend, traces)
+			b.delay = 2
+
+			client, err := NewQueryClient(&QueryConfig{Backend: backend, URL: srv.URL}, modulestest.NewRuntime(t).VU, nil)
+			require.NoError(t, err)
+
+			result, err := client.Verify(newTraces(traces), &VerifyOptions{Interval: "10ms"})
+			require.NoError(t, err)
+			assert.True(t, result.OK, result.Mismatches)
+			assert.Equal(t, 2, result.Traces)
+			assert.Equal(t, 2, result.Found)
+			assert.Empty(t, result.NotFound)
+		})
+	}
+}
+
+func TestQueryClient_VerifyMismatches(t *testing.T) {
+	traces := tracegen.NewParameterizedGenerator([]*tracegen.TraceParams{
+		{Count: 1, Spans: tracegen.SpanParams{Count: 3}},
+	}, nil).Traces()
+	traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes().PutStr("http.request.method", "GET")
+	b, srv := newQueryBackend(t, backendTempo, traces)
+
+	b.modify(func(trace ptrace.Traces) {
+		spans := trace.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+		spans.At(0).SetName("renamed")
+		spans.At(0).Attributes().PutStr("http.request.method", "POST")
+		spans.RemoveIf(func(span ptrace.Span) bool { return span.SpanID() == spans.At(2).SpanID() })
+	})
+
+	client, err := NewQueryClient(&QueryConfig{URL: srv.URL}, modulestest.NewRuntime(t).VU, nil)
+	require.NoError(t, err)
+
+	result, err := client.Verify(newTraces(traces), nil)
+	require.NoError(t, err)
+	assert.False(t, result.OK)
+	assert.Equal(t, 1, result.Found)
+	require.Len(t, result.Mismatches, 4)
+	assert.Contains(t, result.Mismatches[0], "expected 3 spans, got 2")
+	assert.Contains(t, result.Mismatches[1], `expected name`)
+	assert.Contains(t, result.Mismatches[2], `attribute http.request.method: expected "GET", got "POST"`)
+	assert.Contains(t, result.Mismatches[3], "is missing")
+}
+
+func TestQueryClient_VerifyNotFound(t *testing.T) {
+	traces := tracegen.NewParameterizedGenerator([]*tracegen.TraceParams{
+		{Count: 1, Spans: tracegen.SpanParams{Count: 1}},
+	}, nil).Traces()
+	_, srv := newQueryBackend(t, backendTempo, ptrace.NewTraces())
+
+	client, err := NewQueryClient(&QueryConfig{URL: srv.URL}, modulestest.NewRuntime(t).VU, nil)
+	require.NoError(t, err)
+
+	result, err := client.Verify(newTraces(traces), &VerifyOptions{Timeout: "50ms", Interval: "10ms"})
+	require.NoError(t, err)
+	assert.False(t, result.OK)
+	assert.Equal(t, 0, result.Found)
+	assert.Equal(t, []string{splitTraces(traces)[0].id.String()}, result.NotFound)
+}
+
+func TestQueryClient_GetTrace(t *testing.T) {
+	traces := tracegen.NewParameterizedGenerator([]*tracegen.TraceParams{
+		{Count: 1, Spans: tracegen.SpanParams{Count: 2}},
+	}, nil).Traces()
+	_, srv := newQueryBackend(t, backendTempo, traces)
+
+	client, err := NewQueryClient(&QueryConfig{URL: srv.URL, Headers: map[string]string{"X-Scope-OrgID": "k6"}}, modulestest.NewRuntime(t).VU, nil)
+	require.NoError(t, err)
+
+	trace, err := client.GetTrace(splitTraces(traces)[0].id.String())
+	require.NoError(t, err)
+	assert.Equal(t, 2, trace.SpanCount())
+
+	_, err = client.GetTrace("00000000000000000000000000000001")
+	assert.ErrorIs(t, err, errTraceNotFound)
+	_, err = client.GetTrace("not-a-trace-id")
+	assert.Error(t, err)
+}
+
+func TestNewQueryClientInvalid(t *testing.T) {
+	vu := modulestest.NewRuntime(t).VU
+	for name, cfg := range map[string]QueryConfig{
+		"no url":  {},
+		"scheme":  {URL: "tempo:3200"},
+		"backend": {URL: "http://tempo:3200", Backend: "elastic"},
+		"timeout": {URL: "http://tempo:3200", Timeout: "soon"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewQueryClient(&cfg, vu, nil)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestTracingModule_QueryClient(t *testing.T) {
+	traces := tracegen.NewParameterizedGenerator([]*tracegen.TraceParams{
+		{Count: 1, Spans: tracegen.SpanParams{Count: 2}},
+	}, nil).Traces()
+	_, srv := newQueryBackend(t, backendJaeger, traces)
+
+	runtime := modulestest.NewRuntime(t)
+	rt := runtime.VU.Runtime()
+	mi := new(RootModule).NewModuleInstance(runtime.VU)
+	require.NoError(t, rt.Set("tracing", mi.Exports().Named))
+	require.NoError(t, rt.Set("url", srv.URL))
+	require.NoError(t, rt.Set("traces", newTraces(traces)))
+
+	val, err := rt.RunString(`
+		const query = new tracing.QueryClient({url: url, backend: tracing.BACKEND_JAEGER});
+		const result = query.verify(traces);
+		result.ok && result.found === 1 && result.not_found.length === 0 && result.mismatches.length === 0;
+	`)
+	require.NoError(t, err)
+	assert.True(t, val.ToBoolean())
+}
+
+func TestQueryClient_Search(t *testing.T) {
+	traces := tracegen.NewParameterizedGenerator([]*tracegen.TraceParams{
+		{Count: 3, Spans: tracegen.SpanParams{Count: 1}},
+	}, nil).Traces()
+	b, srv := newQueryBackend(t, backendTempo, traces)
+
+	client, err := NewQueryClient(&QueryConfig{URL: srv.URL}, modulestest.NewRuntime(t).VU, nil)
+	require.NoError(t, err)
+
+	query := `{ resource.service.name = "shop-backend" && span.http.response.status_code >= 500 }`
+	result, err := client.Search(query, &SearchOptions{Limit: 10, Since: "15m"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Traces)
+	assert.Equal(t, 3, result.Spans)
+	assert.Len(t, result.TraceIDs, 3)
+	assert.Equal(t, []string{query}, b.queries)
+
+	jaeger, err := NewQueryClient(&QueryConfig{URL: srv.URL, Backend: backendJaeger}, modulestest.NewRuntime(t).VU, nil)
+	require.NoError(t, err)
+	_, err = jaeger.Search(query, nil)
+	assert.Error(t, err)
+}
+
+func TestTracingModule_TraceQLGenerator(t *testing.T) {
+	b, srv := newQueryBackend(t, backendTempo, ptrace.NewTraces())
+
+	runtime := modulestest.NewRuntime(t)
+	rt := runtime.VU.Runtime()
+	mi := new(RootModule).NewModuleInstance(runtime.VU)
+	require.NoError(t, rt.Set("tracing", mi.Exports().Named))
+	require.NoError(t, rt.Set("url", srv.URL))
+
+	val, err := rt.RunString(`
+		const template = {spans: [{service: "shop-backend", name: "checkout"}, {service: "cart-service"}]};
+		const gen = new tracing.TemplatedGenerator(template);
+		const queries = new tracing.TraceQLGenerator(template);
+		const query = new tracing.QueryClient({url: url});
+		query.search(queries.query(), {limit: 5}).traces;
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), val.ToInteger())
+	require.Len(t, b.queries, 1)
+	assert.Contains(t, b.queries[0], "resource.service.name")
+}
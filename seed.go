@@ -0,0 +1,105 @@
+package clienttracing
+
+import (
+	"go.k6.io/k6/v2/js/modules"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/grafana/xk6-client-tracing/pkg/random"
+	"github.com/grafana/xk6-client-tracing/pkg/tracegen"
+)
+
+// GeneratorOptions contains the options that can be passed as second argument to the generator constructors.
+type GeneratorOptions struct {
+	// Seed makes the generated traces deterministic per VU and iteration (optional)
+	Seed *int64 `js:"seed"`
+}
+
+// seededSource reseeds a random source before each use, such that the random values only depend on the seed,
+// the VU, the iteration and the number of previous uses within the iteration.
+type seededSource struct {
+	rnd  *random.Source
+	seed uint64
+	vu   modules.VU
+
+	vuID      uint64
+	iteration int64
+	calls     uint64
+}
+
+func newSeededSource(rnd *random.Source, seed uint64, vu modules.VU) *seededSource {
+	return &seededSource{rnd: rnd, seed: seed, vu: vu, iteration: -2}
+}
+
+// reseed seeds the source for the next use, it must be called on the event loop.
+func (s *seededSource) reseed() {
+	// uses outside an iteration, e.g. in the init context, are treated as an iteration of VU 0
+	var (
+		vuID      uint64
+		iteration int64 = -1
+	)
+	if state := s.vu.State(); state != nil {
+		vuID, iteration = state.VUID, state.Iteration
+	}
+	if vuID != s.vuID || iteration != s.iteration {
+		s.vuID, s.iteration, s.calls = vuID, iteration, 0
+	}
+
+	s.rnd.Seed(random.DeriveSeed(s.seed, vuID, seedValue(iteration), s.calls))
+	s.calls++
+}
+
+// seededGenerator reseeds the random source of a generator before each call of Traces.
+type seededGenerator struct {
+	generator tracegen.Generator
+	source    *seededSource
+}
+
+func (g *seededGenerator) Traces() ptrace.Traces {
+	g.source.reseed()
+	return g.generator.Traces()
+}
+
+// setSeed sets the seed used by all generators and tenant rotations of the VU that are created afterwards and
+// don't have their own seed.
+func (ct *TracingModule) setSeed(seed int64) {
+	s := seedValue(seed)
+	ct.seed = &s
+}
+
+// generatorSource returns the random source for a new generator and the seed if the generator is seeded. The
+// source of a seeded generator is initialized with the seed, so that the initialization of generators is
+// deterministic as well.
+func (ct *TracingModule) generatorSource(opts GeneratorOptions) (*random.Source, *uint64) {
+	seed := ct.seed
+	if opts.Seed != nil {
+		s := seedValue(*opts.Seed)
+		seed = &s
+	}
+	if seed == nil {
+		return random.NewRandomSource(), nil
+	}
+	return random.NewSource(*seed), seed
+}
+
+// wrapGenerator wraps generators for JavaScript, generators with a seed are reseeded for each call of traces.
+func (ct *TracingModule) wrapGenerator(gen tracegen.Generator, rnd *random.Source, seed *uint64) *generator {
+	if seed == nil {
+		return &generator{generator: gen}
+	}
+	return &generator{generator: &seededGenerator{generator: gen, source: newSeededSource(rnd, *seed, ct.vu)}}
+}
+
+// seedValue converts signed values from JavaScript into seeds, negative values are valid seeds as well.
+func seedValue(v int64) uint64 {
+	return uint64(v) //nolint:gosec // the bits are used as they are
+}
+
+// seedTenants sets the seed of the tenant rotation to the seed set by setSeed, unless the tenants have their own
+// seed.
+func (ct *TracingModule) seedTenants(cfg *TenantConfig) {
+	if cfg == nil || cfg.Seed != nil || ct.seed == nil {
+		return
+	}
+	seed := int64(*ct.seed) //nolint:gosec // the bits are used as they are
+	cfg.Seed = &seed
+}
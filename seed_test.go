@@ -0,0 +1,74 @@
+package clienttracing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.k6.io/k6/v2/js/modulestest"
+	"go.k6.io/k6/v2/lib"
+
+	"github.com/grafana/xk6-client-tracing/pkg/tracegen"
+)
+
+func TestSeededGenerator_Traces(t *testing.T) {
+	vu := &modulestest.VU{StateField: &lib.State{VUID: 1}}
+	ct := &TracingModule{vu: vu}
+	ct.setSeed(42)
+
+	newGenerator := func() *seededGenerator {
+		rnd, seed := ct.generatorSource(GeneratorOptions{})
+		gen := tracegen.NewParameterizedGenerator([]*tracegen.TraceParams{{Count: 1, Spans: tracegen.SpanParams{Count: 2}}}, rnd)
+		return ct.wrapGenerator(gen, rnd, seed).generator.(*seededGenerator)
+	}
+	spanIDs := func(gen *seededGenerator) []string {
+		var ids []string
+		for range 2 {
+			span := gen.Traces().ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+			ids = append(ids, span.SpanID().String())
+		}
+		return ids
+	}
+
+	gen1, gen2 := newGenerator(), newGenerator()
+	first := spanIDs(gen1)
+	assert.NotEqual(t, first[0], first[1], "calls within an iteration must differ")
+	assert.Equal(t, first, spanIDs(gen2))
+
+	// the same iteration in a different VU results in different traces
+	vu.StateField.VUID = 2
+	assert.NotEqual(t, first, spanIDs(gen1))
+
+	// the next iteration of the same VU results in different traces
+	vu.StateField.VUID, vu.StateField.Iteration = 1, 1
+	assert.NotEqual(t, first, spanIDs(gen2))
+}
+
+func TestGeneratorSource(t *testing.T) {
+	ct := &TracingModule{}
+	_, seed := ct.generatorSource(GeneratorOptions{})
+	assert.Nil(t, seed)
+
+	ct.setSeed(1)
+	_, seed = ct.generatorSource(GeneratorOptions{})
+	assert.Equal(t, uint64(1), *seed)
+
+	own := int64(-1)
+	_, seed = ct.generatorSource(GeneratorOptions{Seed: &own})
+	assert.Equal(t, seedValue(own), *seed)
+}
+
+func TestSeedTenants(t *testing.T) {
+	ct := &TracingModule{}
+	cfg := &TenantConfig{}
+	ct.seedTenants(cfg)
+	assert.Nil(t, cfg.Seed)
+
+	ct.setSeed(-1)
+	ct.seedTenants(cfg)
+	assert.Equal(t, int64(-1), *cfg.Seed)
+
+	own := int64(7)
+	cfg.Seed = &own
+	ct.seedTenants(cfg)
+	assert.Equal(t, int64(7), *cfg.Seed)
+}
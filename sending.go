@@ -0,0 +1,166 @@
+package clienttracing
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/config/configoptional"
+	"go.opentelemetry.io/collector/config/configretry"
+	"go.opentelemetry.io/collector/exporter/exporterhelper"
+)
+
+// RetryConfig configures how failed pushes are retried. Intervals are durations like "5s" or "500ms".
+type RetryConfig struct {
+	// Enabled whether failed pushes are retried (default: true)
+	Enabled *bool `js:"enabled"`
+	// InitialInterval the time to wait after the first failure (default: "5s")
+	InitialInterval string `js:"initial_interval"`
+	// MaxInterval the upper bound of the time between retries (default: "30s")
+	MaxInterval string `js:"max_interval"`
+	// MaxElapsedTime the maximum time spent retrying a push, "0s" retries forever (default: "5m")
+	MaxElapsedTime string `js:"max_elapsed_time"`
+}
+
+// QueueConfig configures the sending queue of the exporter. The queue is disabled unless it is configured, so that
+// pushes block until the traces were sent. Pushes to an enabled queue return as soon as the traces are queued,
+// unless WaitForResult is set.
+type QueueConfig struct {
+	// Enabled whether pushed traces are queued and sent by a pool of consumers (default: true if the queue is
+	// configured)
+	Enabled *bool `js:"enabled"`
+	// Size the maximum number of queued pushes (default: 1000)
+	Size int64 `js:"size"`
+	// Consumers the number of consumers sending queued traces concurrently (default: 10)
+	Consumers int `js:"consumers"`
+	// BlockOnOverflow whether pushes wait for space in a full queue instead of failing (default: false)
+	BlockOnOverflow bool `js:"block_on_overflow"`
+	// WaitForResult whether pushes wait until the queued traces were sent (default: false)
+	WaitForResult bool `js:"wait_for_result"`
+	// Batch enables batching of queued traces (optional)
+	Batch *BatchConfig `js:"batch"`
+}
+
+// BatchConfig configures how queued traces are batched before they are sent.
+type BatchConfig struct {
+	// Sizer how the size of a batch is measured: "items" counts spans, "bytes" the size of the traces (default: "items")
+	Sizer string `js:"sizer"`
+	// MinSize the size at which a batch is sent (default: 8192)
+	MinSize int64 `js:"min_size"`
+	// MaxSize the size at which a batch is split, 0 means no limit (default: 0)
+	MaxSize int64 `js:"max_size"`
+	// FlushTimeout the time after which a batch is sent regardless of its size (default: "200ms")
+	FlushTimeout string `js:"flush_timeout"`
+	// PartitionKeys the headers of pushes by which batches are partitioned, only headers in PartitionKeys are
+	// sent with batched pushes. The tenant header is added automatically (optional)
+	PartitionKeys []string `js:"partition_keys"`
+}
+
+// applySending applies the timeout, retry and queue configuration of the client to the configuration of an
+// exporter. Settings that are not part of the client configuration keep the defaults of the exporter.
+func (cfg *ClientConfig) applySending(timeout *time.Duration, retry *configretry.BackOffConfig, queue *configoptional.Optional[exporterhelper.QueueBatchConfig]) error {
+	if err := parseDuration(cfg.Timeout, timeout); err != nil {
+		return fmt.Errorf("invalid timeout: %w", err)
+	}
+	if err := cfg.Retry.apply(retry); err != nil {
+		return fmt.Errorf("invalid retry config: %w", err)
+	}
+	var tenantHeader string
+	if cfg.Tenants != nil {
+		tenantHeader = cfg.Tenants.Header
+		if tenantHeader == "" {
+			tenantHeader = defaultTenantHeader
+		}
+	}
+	if err := cfg.Queue.apply(queue, tenantHeader); err != nil {
+		return fmt.Errorf("invalid queue config: %w", err)
+	}
+	return nil
+}
+
+func (cfg *RetryConfig) apply(dst *configretry.BackOffConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	if cfg.Enabled != nil {
+		dst.Enabled = *cfg.Enabled
+	}
+	if err := parseDuration(cfg.InitialInterval, &dst.InitialInterval); err != nil {
+		return fmt.Errorf("initial_interval: %w", err)
+	}
+	if err := parseDuration(cfg.MaxInterval, &dst.MaxInterval); err != nil {
+		return fmt.Errorf("max_interval: %w", err)
+	}
+	if err := parseDuration(cfg.MaxElapsedTime, &dst.MaxElapsedTime); err != nil {
+		return fmt.Errorf("max_elapsed_time: %w", err)
+	}
+	return dst.Validate()
+}
+
+func (cfg *QueueConfig) apply(dst *configoptional.Optional[exporterhelper.QueueBatchConfig], tenantHeader string) error {
+	// without a queue, the push metrics measure the time until the traces were sent instead of the time to
+	// queue them
+	if cfg == nil || (cfg.Enabled != nil && !*cfg.Enabled) {
+		*dst = configoptional.None[exporterhelper.QueueBatchConfig]()
+		return nil
+	}
+
+	if !dst.HasValue() {
+		*dst = configoptional.Some(exporterhelper.NewDefaultQueueConfig())
+	}
+	queue := dst.Get()
+	if cfg.Size > 0 {
+		queue.QueueSize = cfg.Size
+	}
+	if cfg.Consumers > 0 {
+		queue.NumConsumers = cfg.Consumers
+	}
+	queue.BlockOnOverflow = cfg.BlockOnOverflow
+	queue.WaitForResult = cfg.WaitForResult
+
+	if cfg.Batch != nil {
+		batch := queue.Batch.GetOrInsertDefault()
+		if cfg.Batch.Sizer != "" {
+			if err := batch.Sizer.UnmarshalText([]byte(cfg.Batch.Sizer)); err != nil {
+				return fmt.Errorf("batch sizer: %w", err)
+			}
+		}
+		if cfg.Batch.MinSize > 0 {
+			batch.MinSize = cfg.Batch.MinSize
+		}
+		batch.MaxSize = cfg.Batch.MaxSize
+		if err := parseDuration(cfg.Batch.FlushTimeout, &batch.FlushTimeout); err != nil {
+			return fmt.Errorf("batch flush_timeout: %w", err)
+		}
+		batch.Partition.MetadataKeys = slices.Clone(cfg.Batch.PartitionKeys)
+		if tenantHeader != "" && !slices.ContainsFunc(batch.Partition.MetadataKeys, func(key string) bool {
+			return strings.EqualFold(key, tenantHeader)
+		}) {
+			batch.Partition.MetadataKeys = append(batch.Partition.MetadataKeys, tenantHeader)
+		}
+		if err := batch.Partition.Validate(); err != nil {
+			return err
+		}
+		if err := batch.Validate(); err != nil {
+			return err
+		}
+	}
+	return queue.Validate()
+}
+
+// parseDuration parses s into dst, dst is unchanged if s is empty.
+func parseDuration(s string, dst *time.Duration) error {
+	if s == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	if d < 0 {
+		return fmt.Errorf("negative duration %s", s)
+	}
+	*dst = d
+	return nil
+}
@@ -0,0 +1,116 @@
+package clienttracing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/sobek"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/v2/js/common"
+	"go.opentelemetry.io/collector/config/configoptional"
+	"go.opentelemetry.io/collector/config/configretry"
+	"go.opentelemetry.io/collector/exporter/exporterhelper"
+)
+
+func TestClientConfig_ApplySending(t *testing.T) {
+	rt := sobek.New()
+	rt.SetFieldNameMapper(common.FieldNameMapper{})
+	val, err := rt.RunString(`({
+		timeout: "2s",
+		retry: {enabled: true, initial_interval: "100ms", max_interval: "1s", max_elapsed_time: "10s"},
+		queue: {size: 50, consumers: 2, wait_for_result: true, batch: {sizer: "bytes", min_size: 1024, max_size: 4096, flush_timeout: "1s"}},
+	})`)
+	require.NoError(t, err)
+
+	var cfg ClientConfig
+	require.NoError(t, rt.ExportTo(val, &cfg))
+
+	var (
+		timeout = 5 * time.Second
+		retry   = configretry.NewDefaultBackOffConfig()
+		queue   = configoptional.None[exporterhelper.QueueBatchConfig]()
+	)
+	require.NoError(t, cfg.applySending(&timeout, &retry, &queue))
+
+	assert.Equal(t, 2*time.Second, timeout)
+	assert.True(t, retry.Enabled)
+	assert.Equal(t, 100*time.Millisecond, retry.InitialInterval)
+	assert.Equal(t, time.Second, retry.MaxInterval)
+	assert.Equal(t, 10*time.Second, retry.MaxElapsedTime)
+
+	require.True(t, queue.HasValue())
+	assert.Equal(t, int64(50), queue.Get().QueueSize)
+	assert.Equal(t, 2, queue.Get().NumConsumers)
+	assert.True(t, queue.Get().WaitForResult)
+	require.True(t, queue.Get().Batch.HasValue())
+	batch := queue.Get().Batch.Get()
+	assert.Equal(t, exporterhelper.RequestSizerTypeBytes, batch.Sizer)
+	assert.Equal(t, int64(1024), batch.MinSize)
+	assert.Equal(t, int64(4096), batch.MaxSize)
+	assert.Equal(t, time.Second, batch.FlushTimeout)
+}
+
+func TestClientConfig_ApplySendingDefaults(t *testing.T) {
+	disabled := false
+	cfg := ClientConfig{
+		Retry: &RetryConfig{Enabled: &disabled},
+		Queue: &QueueConfig{Enabled: &disabled},
+	}
+
+	var (
+		timeout = 5 * time.Second
+		retry   = configretry.NewDefaultBackOffConfig()
+		queue   = configoptional.Some(exporterhelper.NewDefaultQueueConfig())
+	)
+	require.NoError(t, cfg.applySending(&timeout, &retry, &queue))
+
+	assert.Equal(t, 5*time.Second, timeout)
+	assert.False(t, retry.Enabled)
+	assert.Equal(t, configretry.NewDefaultBackOffConfig().InitialInterval, retry.InitialInterval)
+	assert.False(t, queue.HasValue())
+
+	// the queue is disabled unless it is configured
+	queue = configoptional.Some(exporterhelper.NewDefaultQueueConfig())
+	require.NoError(t, (&ClientConfig{}).applySending(&timeout, &retry, &queue))
+	assert.False(t, queue.HasValue())
+}
+
+func TestClientConfig_ApplySendingInvalid(t *testing.T) {
+	for name, cfg := range map[string]ClientConfig{
+		"timeout":        {Timeout: "soon"},
+		"negative":       {Timeout: "-1s"},
+		"retry interval": {Retry: &RetryConfig{InitialInterval: "1"}},
+		"batch sizer":    {Queue: &QueueConfig{Batch: &BatchConfig{Sizer: "spans"}}},
+		"batch size":     {Queue: &QueueConfig{Batch: &BatchConfig{MinSize: 100, MaxSize: 10}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var (
+				timeout time.Duration
+				retry   = configretry.NewDefaultBackOffConfig()
+				queue   = configoptional.Some(exporterhelper.NewDefaultQueueConfig())
+			)
+			assert.Error(t, cfg.applySending(&timeout, &retry, &queue))
+		})
+	}
+}
+
+func TestClientConfig_ApplySendingTenantPartition(t *testing.T) {
+	cfg := ClientConfig{
+		Tenants: &TenantConfig{IDs: []string{"a", "b"}},
+		Queue:   &QueueConfig{Batch: &BatchConfig{PartitionKeys: []string{"X-Source"}}},
+	}
+
+	var (
+		timeout time.Duration
+		retry   = configretry.NewDefaultBackOffConfig()
+		queue   = configoptional.Some(exporterhelper.NewDefaultQueueConfig())
+	)
+	require.NoError(t, cfg.applySending(&timeout, &retry, &queue))
+	assert.Equal(t, []string{"X-Source", defaultTenantHeader}, queue.Get().Batch.Get().Partition.MetadataKeys)
+
+	// the tenant header is not added twice
+	cfg.Queue.Batch.PartitionKeys = []string{"x-scope-orgid"}
+	require.NoError(t, cfg.applySending(&timeout, &retry, &queue))
+	assert.Equal(t, []string{"x-scope-orgid"}, queue.Get().Batch.Get().Partition.MetadataKeys)
+}
@@ -0,0 +1,309 @@
+package clienttracing
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/grafana/xk6-client-tracing/pkg/tracegen"
+)
+
+const attrServiceName = "service.name"
+
+const (
+	statusUnset = "unset"
+	statusOK    = "ok"
+	statusError = "error"
+)
+
+// Traces is the JavaScript representation of the traces returned by generators. It can be passed to push,
+// pushAsync and verify and provides methods to inspect the traces.
+type Traces struct {
+	traces ptrace.Traces
+}
+
+func newTraces(traces ptrace.Traces) *Traces {
+	return &Traces{traces: traces}
+}
+
+// TraceIds returns the hex encoded ids of all traces in the order they first appear.
+func (t *Traces) TraceIds() []string { //nolint:revive // the name is part of the JavaScript API
+	ids := []string{}
+	seen := map[pcommon.TraceID]struct{}{}
+	forEachSpan(t.traces, func(_ ptrace.ResourceSpans, span ptrace.Span) {
+		if _, ok := seen[span.TraceID()]; !ok {
+			seen[span.TraceID()] = struct{}{}
+			ids = append(ids, span.TraceID().String())
+		}
+	})
+	return ids
+}
+
+// SpanCount returns the number of spans.
+func (t *Traces) SpanCount() int {
+	return t.traces.SpanCount()
+}
+
+// SizeBytes returns the size of the traces encoded as OTLP protobuf, which is the size of an uncompressed push.
+func (t *Traces) SizeBytes() int {
+	return (&ptrace.ProtoMarshaler{}).TracesSize(t.traces)
+}
+
+// Services returns the sorted names of the services of all resources.
+func (t *Traces) Services() []string {
+	services := []string{}
+	for i := 0; i < t.traces.ResourceSpans().Len(); i++ {
+		name, ok := t.traces.ResourceSpans().At(i).Resource().Attributes().Get(attrServiceName)
+		if ok && !slices.Contains(services, name.AsString()) {
+			services = append(services, name.AsString())
+		}
+	}
+	slices.Sort(services)
+	return services
+}
+
+// ToJSON returns the traces in the OTLP JSON format. It is called by JSON.stringify, so that traces can be logged
+// and stored as JSON.
+func (t *Traces) ToJSON() (any, error) {
+	data, err := (&ptrace.JSONMarshaler{}).MarshalTraces(t.traces)
+	if err != nil {
+		return nil, err
+	}
+	var v any
+	err = json.Unmarshal(data, &v)
+	return v, err
+}
+
+// ForEachSpan calls fn for every span, the span can be modified by fn.
+func (t *Traces) ForEachSpan(fn func(*Span)) {
+	forEachSpan(t.traces, func(rs ptrace.ResourceSpans, span ptrace.Span) {
+		fn(&Span{span: span, resource: rs.Resource()})
+	})
+}
+
+// SetAttribute sets an attribute of all spans for which the optional predicate returns true. Traces are modified in
+// place and returned to allow chaining.
+func (t *Traces) SetAttribute(key string, value any, predicate func(*Span) bool) (*Traces, error) {
+	v := pcommon.NewValueEmpty()
+	if err := v.FromRaw(value); err != nil {
+		return nil, fmt.Errorf("invalid value of attribute %s: %w", key, err)
+	}
+	t.update(predicate, func(span *Span) { v.CopyTo(span.span.Attributes().PutEmpty(key)) })
+	return t, nil
+}
+
+// SetResourceAttribute sets an attribute of the resources of all spans for which the optional predicate returns
+// true. A resource is shared by all spans of a service within a trace, e.g. setting service.name moves all of
+// these spans to another service.
+func (t *Traces) SetResourceAttribute(key string, value any, predicate func(*Span) bool) (*Traces, error) {
+	v := pcommon.NewValueEmpty()
+	if err := v.FromRaw(value); err != nil {
+		return nil, fmt.Errorf("invalid value of resource attribute %s: %w", key, err)
+	}
+	for i := 0; i < t.traces.ResourceSpans().Len(); i++ {
+		rs := t.traces.ResourceSpans().At(i)
+		if predicate == nil || anySpan(rs, predicate) {
+			v.CopyTo(rs.Resource().Attributes().PutEmpty(key))
+		}
+	}
+	return t, nil
+}
+
+// SetStatus sets the status of all spans for which the optional predicate returns true.
+func (t *Traces) SetStatus(code, message string, predicate func(*Span) bool) (*Traces, error) {
+	statusCode, err := parseStatusCode(code)
+	if err != nil {
+		return nil, err
+	}
+	t.update(predicate, func(span *Span) { setStatus(span.span, statusCode, message) })
+	return t, nil
+}
+
+// Rename sets the name of all spans for which the optional predicate returns true.
+func (t *Traces) Rename(name string, predicate func(*Span) bool) *Traces {
+	t.update(predicate, func(span *Span) { span.span.SetName(name) })
+	return t
+}
+
+// Filter returns a copy that only contains the spans for which predicate returns true, the traces are not
+// modified. Children of removed spans are kept and reference their removed parent.
+func (t *Traces) Filter(predicate func(*Span) bool) *Traces {
+	traces := ptrace.NewTraces()
+	t.traces.CopyTo(traces)
+	if predicate == nil {
+		return newTraces(traces)
+	}
+
+	traces.ResourceSpans().RemoveIf(func(rs ptrace.ResourceSpans) bool {
+		rs.ScopeSpans().RemoveIf(func(ss ptrace.ScopeSpans) bool {
+			ss.Spans().RemoveIf(func(span ptrace.Span) bool {
+				return !predicate(&Span{span: span, resource: rs.Resource()})
+			})
+			return ss.Spans().Len() == 0
+		})
+		return rs.ScopeSpans().Len() == 0
+	})
+	return newTraces(traces)
+}
+
+// update calls fn for all spans for which predicate returns true, fn is called for all spans if predicate is nil.
+func (t *Traces) update(predicate func(*Span) bool, fn func(*Span)) {
+	t.ForEachSpan(func(span *Span) {
+		if predicate == nil || predicate(span) {
+			fn(span)
+		}
+	})
+}
+
+// anySpan returns true if predicate returns true for at least one span of the resource spans.
+func anySpan(rs ptrace.ResourceSpans, predicate func(*Span) bool) bool {
+	for j := 0; j < rs.ScopeSpans().Len(); j++ {
+		spans := rs.ScopeSpans().At(j).Spans()
+		for k := 0; k < spans.Len(); k++ {
+			if predicate(&Span{span: spans.At(k), resource: rs.Resource()}) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// forEachSpan calls fn for every span together with the resource spans it belongs to.
+func forEachSpan(traces ptrace.Traces, fn func(ptrace.ResourceSpans, ptrace.Span)) {
+	for i := 0; i < traces.ResourceSpans().Len(); i++ {
+		rs := traces.ResourceSpans().At(i)
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			spans := rs.ScopeSpans().At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				fn(rs, spans.At(k))
+			}
+		}
+	}
+}
+
+// generator is the JavaScript representation of a generator, the traces are returned as Traces.
+type generator struct {
+	generator tracegen.Generator
+}
+
+func (g *generator) Traces() *Traces {
+	return newTraces(g.generator.Traces())
+}
+
+// Span is the JavaScript representation of a span that is passed to the callbacks and predicates of Traces.
+// Changes of a span modify the traces it belongs to.
+type Span struct {
+	span     ptrace.Span
+	resource pcommon.Resource
+}
+
+func (s *Span) TraceId() string { //nolint:revive // the name is part of the JavaScript API
+	return s.span.TraceID().String()
+}
+
+func (s *Span) SpanId() string { //nolint:revive // the name is part of the JavaScript API
+	return s.span.SpanID().String()
+}
+
+// ParentSpanId returns the id of the parent span or an empty string for root spans.
+func (s *Span) ParentSpanId() string { //nolint:revive // the name is part of the JavaScript API
+	if s.span.ParentSpanID().IsEmpty() {
+		return ""
+	}
+	return s.span.ParentSpanID().String()
+}
+
+func (s *Span) IsRoot() bool {
+	return s.span.ParentSpanID().IsEmpty()
+}
+
+func (s *Span) Name() string {
+	return s.span.Name()
+}
+
+// Kind returns the kind in lower case, e.g. server.
+func (s *Span) Kind() string {
+	return strings.ToLower(s.span.Kind().String())
+}
+
+// Service returns the service.name of the resource of the span.
+func (s *Span) Service() string {
+	name, _ := s.resource.Attributes().Get(attrServiceName)
+	return name.AsString()
+}
+
+// Status returns the status code, which is one of unset, ok and error.
+func (s *Span) Status() string {
+	switch s.span.Status().Code() {
+	case ptrace.StatusCodeOk:
+		return statusOK
+	case ptrace.StatusCodeError:
+		return statusError
+	default:
+		return statusUnset
+	}
+}
+
+// Attribute returns the value of a span attribute or null if the attribute doesn't exist.
+func (s *Span) Attribute(key string) any {
+	v, found := s.span.Attributes().Get(key)
+	if !found {
+		return nil
+	}
+	return v.AsRaw()
+}
+
+// ResourceAttribute returns the value of a resource attribute or null if the attribute doesn't exist.
+func (s *Span) ResourceAttribute(key string) any {
+	v, found := s.resource.Attributes().Get(key)
+	if !found {
+		return nil
+	}
+	return v.AsRaw()
+}
+
+func (s *Span) SetAttribute(key string, value any) error {
+	if err := s.span.Attributes().PutEmpty(key).FromRaw(value); err != nil {
+		return fmt.Errorf("invalid value of attribute %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *Span) SetStatus(code, message string) error {
+	statusCode, err := parseStatusCode(code)
+	if err != nil {
+		return err
+	}
+	setStatus(s.span, statusCode, message)
+	return nil
+}
+
+func (s *Span) Rename(name string) {
+	s.span.SetName(name)
+}
+
+func parseStatusCode(code string) (ptrace.StatusCode, error) {
+	switch code {
+	case statusUnset:
+		return ptrace.StatusCodeUnset, nil
+	case statusOK:
+		return ptrace.StatusCodeOk, nil
+	case statusError:
+		return ptrace.StatusCodeError, nil
+	default:
+		return 0, fmt.Errorf("invalid status code %q, expected one of %s, %s and %s", code, statusUnset, statusOK, statusError)
+	}
+}
+
+// setStatus sets the status of a span, the message is only kept for errors as defined by the specification.
+func setStatus(span ptrace.Span, code ptrace.StatusCode, message string) {
+	span.Status().SetCode(code)
+	if code != ptrace.StatusCodeError {
+		message = ""
+	}
+	span.Status().SetMessage(message)
+}
@@ -0,0 +1,161 @@
+package clienttracing
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/v2/js/modulestest"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/grafana/xk6-client-tracing/pkg/tracegen"
+)
+
+func TestTraces(t *testing.T) {
+	template := tracegen.TraceTemplate{
+		Spans: []tracegen.SpanTemplate{
+			{Service: "shop-backend"},
+			{Service: "cart-service"},
+			{Service: "article-service"},
+		},
+	}
+	gen, err := tracegen.NewTemplatedGenerator(&template, nil)
+	require.NoError(t, err)
+	first, second := gen.Traces(), gen.Traces()
+	second.ResourceSpans().MoveAndAppendTo(first.ResourceSpans())
+	traces := newTraces(first)
+
+	ids := traces.TraceIds()
+	require.Len(t, ids, 2)
+	assert.NotEqual(t, ids[0], ids[1])
+	assert.Equal(t, first.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).TraceID().String(), ids[0])
+	assert.Equal(t, 6, traces.SpanCount())
+	assert.Equal(t, []string{"article-service", "cart-service", "shop-backend"}, traces.Services())
+
+	data, err := (&ptrace.ProtoMarshaler{}).MarshalTraces(first)
+	require.NoError(t, err)
+	assert.Equal(t, len(data), traces.SizeBytes())
+
+	v, err := traces.ToJSON()
+	require.NoError(t, err)
+	data, err = json.Marshal(v)
+	require.NoError(t, err)
+	parsed, err := (&ptrace.JSONUnmarshaler{}).UnmarshalTraces(data)
+	require.NoError(t, err)
+	assert.Equal(t, first, parsed)
+}
+
+func TestTraces_Empty(t *testing.T) {
+	traces := newTraces(ptrace.NewTraces())
+	assert.Empty(t, traces.TraceIds())
+	assert.Equal(t, 0, traces.SpanCount())
+	assert.Empty(t, traces.Services())
+}
+
+func TestTracingModule_Traces(t *testing.T) {
+	runtime := modulestest.NewRuntime(t)
+	rt := runtime.VU.Runtime()
+	mi := new(RootModule).NewModuleInstance(runtime.VU)
+	require.NoError(t, rt.Set("tracing", mi.Exports().Named))
+
+	val, err := rt.RunString(`
+		const gen = new tracing.ParameterizedGenerator([{count: 2, spans: {count: 3}}]);
+		const traces = gen.traces();
+		const json = JSON.parse(JSON.stringify(traces));
+		[
+			traces.traceIds().length === 2,
+			traces.traceIds()[0].length === 32,
+			traces.spanCount() === 6,
+			traces.sizeBytes() > 0,
+			traces.services().length > 0,
+			json.resourceSpans.length > 0,
+		].every((ok) => ok);
+	`)
+	require.NoError(t, err)
+	assert.True(t, val.ToBoolean())
+}
+
+func TestTraces_Mutations(t *testing.T) {
+	gen := tracegen.NewParameterizedGenerator([]*tracegen.TraceParams{{Count: 2, Spans: tracegen.SpanParams{Count: 3}}}, nil)
+	traces := newTraces(gen.Traces())
+	isRoot := func(span *Span) bool { return span.IsRoot() }
+
+	_, err := traces.SetAttribute("scenario", "checkout", nil)
+	require.NoError(t, err)
+	_, err = traces.SetAttribute("retries", int64(2), isRoot)
+	require.NoError(t, err)
+	_, err = traces.SetStatus(statusError, "failed", isRoot)
+	require.NoError(t, err)
+	traces.Rename("root", isRoot)
+	_, err = traces.SetResourceAttribute(attrServiceName, "renamed", isRoot)
+	require.NoError(t, err)
+
+	var roots, children int
+	traces.ForEachSpan(func(span *Span) {
+		assert.Equal(t, "checkout", span.Attribute("scenario"))
+		if !span.IsRoot() {
+			children++
+			assert.NotEqual(t, "root", span.Name())
+			assert.Nil(t, span.Attribute("retries"))
+			assert.Equal(t, statusOK, span.Status())
+			return
+		}
+		roots++
+		assert.Equal(t, "root", span.Name())
+		assert.Equal(t, int64(2), span.Attribute("retries"))
+		assert.Equal(t, statusError, span.Status())
+		assert.Equal(t, "failed", span.span.Status().Message())
+		assert.Equal(t, "renamed", span.Service())
+	})
+	assert.Equal(t, 2, roots)
+	assert.Equal(t, 4, children)
+
+	_, err = traces.SetStatus("failed", "", nil)
+	assert.Error(t, err)
+	_, err = traces.SetAttribute("invalid", struct{}{}, nil)
+	assert.Error(t, err)
+}
+
+func TestTraces_Filter(t *testing.T) {
+	gen := tracegen.NewParameterizedGenerator([]*tracegen.TraceParams{{Count: 2, Spans: tracegen.SpanParams{Count: 3}}}, nil)
+	traces := newTraces(gen.Traces())
+	first := traces.TraceIds()[0]
+
+	filtered := traces.Filter(func(span *Span) bool { return span.TraceId() == first })
+	assert.Equal(t, []string{first}, filtered.TraceIds())
+	assert.Equal(t, 3, filtered.SpanCount())
+	assert.Equal(t, 6, traces.SpanCount())
+
+	assert.Equal(t, 0, traces.Filter(func(*Span) bool { return false }).SpanCount())
+	assert.Equal(t, 0, traces.Filter(func(*Span) bool { return false }).traces.ResourceSpans().Len())
+}
+
+func TestTracingModule_TracesMutations(t *testing.T) {
+	runtime := modulestest.NewRuntime(t)
+	rt := runtime.VU.Runtime()
+	mi := new(RootModule).NewModuleInstance(runtime.VU)
+	require.NoError(t, rt.Set("tracing", mi.Exports().Named))
+
+	val, err := rt.RunString(`
+		const gen = new tracing.ParameterizedGenerator([{count: 1, spans: {count: 3}}]);
+		const traces = gen.traces()
+			.setAttribute("test.id", 42)
+			.setStatus(tracing.STATUS_ERROR, "forced error", (span) => span.isRoot())
+			.rename("checkout", (span) => span.isRoot());
+		traces.forEachSpan((span) => {
+			if (!span.isRoot()) {
+				span.setAttribute("tags", ["a", "b"]);
+			}
+		});
+		const roots = traces.filter((span) => span.status() === tracing.STATUS_ERROR);
+		const tagged = [];
+		traces.forEachSpan((span) => tagged.push(span.attribute("test.id") === 42 && (span.isRoot() || span.attribute("tags").length === 2)));
+		roots.spanCount() === 1 && traces.spanCount() === 3 && tagged.every((ok) => ok);
+	`)
+	require.NoError(t, err)
+	assert.True(t, val.ToBoolean())
+
+	_, err = rt.RunString(`gen.traces().setStatus("failed")`)
+	assert.ErrorContains(t, err, "invalid status code")
+}
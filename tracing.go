@@ -2,16 +2,18 @@ package clienttracing
 
 import (
 	"context"
-	"encoding/base64"
+	"errors"
 	"fmt"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/grafana/sobek"
 	"go.k6.io/k6/v2/js/common"
 	"go.k6.io/k6/v2/js/modules"
+	"go.k6.io/k6/v2/js/promises"
+	"go.k6.io/k6/v2/metrics"
 	"go.opentelemetry.io/collector/component"
-	"go.opentelemetry.io/collector/component/componenttest"
 	"go.opentelemetry.io/collector/config/configcompression"
 	"go.opentelemetry.io/collector/config/configgrpc"
 	"go.opentelemetry.io/collector/config/confighttp"
@@ -26,17 +28,27 @@ import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
+	"github.com/grafana/xk6-client-tracing/pkg/exporter/fileexporter"
+	"github.com/grafana/xk6-client-tracing/pkg/exporter/jaegerexporter"
+	"github.com/grafana/xk6-client-tracing/pkg/exporter/zipkinexporter"
 	"github.com/grafana/xk6-client-tracing/pkg/tracegen"
 )
 
 type exporterType string
 
 const (
-	exporterNone     exporterType = ""
-	exporterOTLP     exporterType = "otlp"
-	exporterOTLPHTTP exporterType = "otlphttp"
+	exporterNone             exporterType = ""
+	exporterOTLP             exporterType = "otlp"
+	exporterOTLPHTTP         exporterType = "otlphttp"
+	exporterZipkin           exporterType = "zipkin"
+	exporterJaeger           exporterType = "jaeger"
+	exporterJaegerThriftHTTP exporterType = "jaegerthrifthttp"
+	exporterFile             exporterType = "file"
 )
 
+// shutdownTimeout limits the time the automatic shutdown of a client spends flushing queued traces.
+const shutdownTimeout = 10 * time.Second
+
 var (
 	_ modules.Module   = &RootModule{}
 	_ modules.Instance = &TracingModule{}
@@ -52,31 +64,62 @@ type RootModule struct {
 
 func (r *RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
 	return &TracingModule{
-		vu:                  vu,
-		paramGenerators:     make(map[*sobek.Object]*tracegen.ParameterizedGenerator),
-		templatedGenerators: make(map[*sobek.Object]*tracegen.TemplatedGenerator),
+		vu:         vu,
+		metrics:    registerMetrics(vu.InitEnv().Registry),
+		generators: make(map[*sobek.Object]*generator),
 	}
 }
 
 type TracingModule struct {
-	vu                  modules.VU
-	client              *Client
-	paramGenerators     map[*sobek.Object]*tracegen.ParameterizedGenerator
-	templatedGenerators map[*sobek.Object]*tracegen.TemplatedGenerator
+	vu      modules.VU
+	metrics *tracingMetrics
+	// generators contains the generators by the object they were created from, which is either the trace
+	// parameters, the template or the file parameters
+	generators map[*sobek.Object]*generator
+	seed       *uint64
 }
 
 func (ct *TracingModule) Exports() modules.Exports {
 	return modules.Exports{
 		Named: map[string]interface{}{
 			// constants
-			"SEMANTICS_HTTP":     tracegen.SemanticsHTTP,
-			"SEMANTICS_DB":       tracegen.SemanticsDB,
-			"EXPORTER_OTLP":      exporterOTLP,
-			"EXPORTER_OTLP_HTTP": exporterOTLPHTTP,
+			"SEMANTICS_HTTP":              tracegen.SemanticsHTTP,
+			"SEMANTICS_DB":                tracegen.SemanticsDB,
+			"SEMANTICS_MESSAGING":         tracegen.SemanticsMessaging,
+			"SEMANTICS_RPC":               tracegen.SemanticsRPC,
+			"DISTRIBUTION_UNIFORM":        tracegen.DistributionUniform,
+			"DISTRIBUTION_FIXED":          tracegen.DistributionFixed,
+			"DISTRIBUTION_NORMAL":         tracegen.DistributionNormal,
+			"DISTRIBUTION_EXPONENTIAL":    tracegen.DistributionExponential,
+			"DISTRIBUTION_LOGNORMAL":      tracegen.DistributionLogNormal,
+			"DISTRIBUTION_PERCENTILES":    tracegen.DistributionPercentiles,
+			"TIMING_PARALLEL":             tracegen.TimingParallel,
+			"TIMING_SEQUENTIAL":           tracegen.TimingSequential,
+			"EXPORTER_OTLP":               exporterOTLP,
+			"EXPORTER_OTLP_HTTP":          exporterOTLPHTTP,
+			"EXPORTER_ZIPKIN":             exporterZipkin,
+			"EXPORTER_JAEGER":             exporterJaeger,
+			"EXPORTER_JAEGER_THRIFT_HTTP": exporterJaegerThriftHTTP,
+			"EXPORTER_FILE":               exporterFile,
+			"REPLAY_SEQUENTIAL":           tracegen.ReplaySequential,
+			"REPLAY_RANDOM":               tracegen.ReplayRandom,
+			"REPLAY_ROUND_ROBIN":          tracegen.ReplayRoundRobin,
+			"BACKEND_TEMPO":               backendTempo,
+			"BACKEND_JAEGER":              backendJaeger,
+			"STATUS_UNSET":                statusUnset,
+			"STATUS_OK":                   statusOK,
+			"STATUS_ERROR":                statusError,
+			// functions
+			"setSeed": ct.setSeed,
 			// constructors
 			"Client":                 ct.newClient,
+			"QueryClient":            ct.newQueryClient,
+			"MockReceiver":           ct.newMockReceiver,
 			"ParameterizedGenerator": ct.newParameterizedGenerator,
 			"TemplatedGenerator":     ct.newTemplatedGenerator,
+			"TraceQLGenerator":       ct.newTraceQLGenerator,
+			"FileGenerator":          ct.newFileGenerator,
+			"TopologyGenerator":      ct.newTopologyGenerator,
 		},
 	}
 }
@@ -88,21 +131,35 @@ func (ct *TracingModule) newClient(g sobek.ConstructorCall, rt *sobek.Runtime) *
 		common.Throw(rt, fmt.Errorf("unable to create client: constructor expects first argument to be ClientConfig: %w", err))
 	}
 
-	if ct.client == nil {
-		ct.client, err = NewClient(&cfg, ct.vu)
-		if err != nil {
-			common.Throw(rt, fmt.Errorf("unable to create client: %w", err))
-		}
+	ct.seedTenants(cfg.Tenants)
+	client, err := NewClient(&cfg, ct.vu, ct.metrics)
+	if err != nil {
+		common.Throw(rt, fmt.Errorf("unable to create client: %w", err))
+	}
+
+	return rt.ToValue(client).ToObject(rt)
+}
+
+func (ct *TracingModule) newQueryClient(g sobek.ConstructorCall, rt *sobek.Runtime) *sobek.Object {
+	var cfg QueryConfig
+	err := rt.ExportTo(g.Argument(0), &cfg)
+	if err != nil {
+		common.Throw(rt, fmt.Errorf("unable to create query client: constructor expects first argument to be QueryConfig: %w", err))
 	}
 
-	return rt.ToValue(ct.client).ToObject(rt)
+	client, err := NewQueryClient(&cfg, ct.vu, ct.metrics)
+	if err != nil {
+		common.Throw(rt, fmt.Errorf("unable to create query client: %w", err))
+	}
+
+	return rt.ToValue(client).ToObject(rt)
 }
 
 func (ct *TracingModule) newParameterizedGenerator(g sobek.ConstructorCall, rt *sobek.Runtime) *sobek.Object {
 	paramVal := g.Argument(0)
 	paramObj := paramVal.ToObject(rt)
 
-	generator, found := ct.paramGenerators[paramObj]
+	generator, found := ct.generators[paramObj]
 	if !found {
 		var param []*tracegen.TraceParams
 		err := rt.ExportTo(paramVal, &param)
@@ -110,36 +167,125 @@ func (ct *TracingModule) newParameterizedGenerator(g sobek.ConstructorCall, rt *
 			common.Throw(rt, fmt.Errorf("the ParameterizedGenerator constructor expects first argument to be []TraceParams: %w", err))
 		}
 
-		generator = tracegen.NewParameterizedGenerator(param)
-		ct.paramGenerators[paramObj] = generator
+		rnd, seed := ct.generatorSource(ct.generatorOptions(g, rt))
+		generator = ct.wrapGenerator(tracegen.NewParameterizedGenerator(param, rnd), rnd, seed)
+		ct.generators[paramObj] = generator
 	}
 
 	return rt.ToValue(generator).ToObject(rt)
 }
 
 func (ct *TracingModule) newTemplatedGenerator(g sobek.ConstructorCall, rt *sobek.Runtime) *sobek.Object {
+	generator, _ := ct.templatedGenerator(g, rt, "TemplatedGenerator")
+	return rt.ToValue(generator).ToObject(rt)
+}
+
+func (ct *TracingModule) newTraceQLGenerator(g sobek.ConstructorCall, rt *sobek.Runtime) *sobek.Object {
+	// the queries are created from the same generator that creates the traces of the template, such that random
+	// span names are the same
+	_, gen := ct.templatedGenerator(g, rt, "TraceQLGenerator")
+	rnd, _ := ct.generatorSource(ct.generatorOptions(g, rt))
+	return rt.ToValue(tracegen.NewTraceQLGenerator(gen, rnd)).ToObject(rt)
+}
+
+// templatedGenerator returns the generator for the template passed as first argument to the constructor, the
+// generator is created if it doesn't exist yet. Besides the generator as it is exported to JavaScript, the
+// underlying TemplatedGenerator is returned.
+func (ct *TracingModule) templatedGenerator(g sobek.ConstructorCall, rt *sobek.Runtime, constructor string) (*generator, *tracegen.TemplatedGenerator) {
 	tmplVal := g.Argument(0)
 	tmplObj := tmplVal.ToObject(rt)
 
-	generator, found := ct.templatedGenerators[tmplObj]
+	generator, found := ct.generators[tmplObj]
 	if !found {
 		var tmpl tracegen.TraceTemplate
 		err := rt.ExportTo(tmplVal, &tmpl)
 		if err != nil {
-			common.Throw(rt, fmt.Errorf("the TemplatedGenerator constructor expects first argument to be TraceTemplate: %w", err))
+			common.Throw(rt, fmt.Errorf("the %s constructor expects first argument to be TraceTemplate: %w", constructor, err))
 		}
 
-		generator, err = tracegen.NewTemplatedGenerator(&tmpl)
+		rnd, seed := ct.generatorSource(ct.generatorOptions(g, rt))
+		gen, err := tracegen.NewTemplatedGenerator(&tmpl, rnd)
 		if err != nil {
 			common.Throw(rt, fmt.Errorf("unable to generate TemplatedGenerator: %w", err))
 		}
 
-		ct.templatedGenerators[tmplObj] = generator
+		generator = ct.wrapGenerator(gen, rnd, seed)
+		ct.generators[tmplObj] = generator
+	}
+
+	switch gen := generator.generator.(type) {
+	case *tracegen.TemplatedGenerator:
+		return generator, gen
+	case *seededGenerator:
+		if tg, ok := gen.generator.(*tracegen.TemplatedGenerator); ok {
+			return generator, tg
+		}
+	}
+	common.Throw(rt, fmt.Errorf("the %s constructor expects first argument to be TraceTemplate", constructor))
+	return nil, nil
+}
+
+func (ct *TracingModule) newFileGenerator(g sobek.ConstructorCall, rt *sobek.Runtime) *sobek.Object {
+	paramVal := g.Argument(0)
+	paramObj := paramVal.ToObject(rt)
+
+	generator, found := ct.generators[paramObj]
+	if !found {
+		var param tracegen.FileParams
+		err := rt.ExportTo(paramVal, &param)
+		if err != nil {
+			common.Throw(rt, fmt.Errorf("the FileGenerator constructor expects first argument to be FileParams: %w", err))
+		}
+
+		rnd, seed := ct.generatorSource(ct.generatorOptions(g, rt))
+		gen, err := tracegen.NewFileGenerator(&param, rnd)
+		if err != nil {
+			common.Throw(rt, fmt.Errorf("unable to create FileGenerator: %w", err))
+		}
+
+		generator = ct.wrapGenerator(gen, rnd, seed)
+		ct.generators[paramObj] = generator
 	}
 
 	return rt.ToValue(generator).ToObject(rt)
 }
 
+func (ct *TracingModule) newTopologyGenerator(g sobek.ConstructorCall, rt *sobek.Runtime) *sobek.Object {
+	topologyVal := g.Argument(0)
+	topologyObj := topologyVal.ToObject(rt)
+
+	generator, found := ct.generators[topologyObj]
+	if !found {
+		var topology tracegen.Topology
+		err := rt.ExportTo(topologyVal, &topology)
+		if err != nil {
+			common.Throw(rt, fmt.Errorf("the TopologyGenerator constructor expects first argument to be Topology: %w", err))
+		}
+
+		rnd, seed := ct.generatorSource(ct.generatorOptions(g, rt))
+		gen, err := tracegen.NewTopologyGenerator(&topology, rnd)
+		if err != nil {
+			common.Throw(rt, fmt.Errorf("unable to create TopologyGenerator: %w", err))
+		}
+
+		generator = ct.wrapGenerator(gen, rnd, seed)
+		ct.generators[topologyObj] = generator
+	}
+
+	return rt.ToValue(generator).ToObject(rt)
+}
+
+// generatorOptions returns the options passed as optional second argument to a generator constructor.
+func (ct *TracingModule) generatorOptions(g sobek.ConstructorCall, rt *sobek.Runtime) GeneratorOptions {
+	var opts GeneratorOptions
+	if optsVal := g.Argument(1); !sobek.IsUndefined(optsVal) && !sobek.IsNull(optsVal) {
+		if err := rt.ExportTo(optsVal, &opts); err != nil {
+			common.Throw(rt, fmt.Errorf("the generator constructors expect second argument to be GeneratorOptions: %w", err))
+		}
+	}
+	return opts
+}
+
 type TLSClientConfig struct {
 	Insecure           bool   `js:"insecure"`
 	InsecureSkipVerify bool   `js:"insecure_skip_verify"`
@@ -149,27 +295,82 @@ type TLSClientConfig struct {
 	KeyFile            string `js:"key_file"`
 }
 
-type ClientConfig struct {
-	Exporter       exporterType    `js:"exporter"`
-	Endpoint       string          `js:"endpoint"`
-	TLS            TLSClientConfig `js:"tls"`
-	Authentication struct {
-		User     string `js:"user"`
-		Password string `js:"password"`
+func (cfg TLSClientConfig) clientConfig() configtls.ClientConfig {
+	return configtls.ClientConfig{
+		Insecure:           cfg.Insecure,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+		ServerName:         cfg.ServerName,
+		Config: configtls.Config{
+			CAFile:   cfg.CAFile,
+			CertFile: cfg.CertFile,
+			KeyFile:  cfg.KeyFile,
+		},
 	}
+}
+
+type FileConfig struct {
+	Path     string `js:"path"`
+	Format   string `js:"format"`
+	Rotation struct {
+		MaxMegabytes int `js:"max_megabytes"`
+		MaxBackups   int `js:"max_backups"`
+	} `js:"rotation"`
+}
+
+type ClientConfig struct {
+	Exporter          exporterType                        `js:"exporter"`
+	Endpoint          string                              `js:"endpoint"`
+	TLS               TLSClientConfig                     `js:"tls"`
+	Authentication    AuthenticationConfig                `js:"authentication"`
 	Headers           map[string]configopaque.String      `js:"headers"`
 	Compression       configcompression.Type              `js:"compression"`
 	CompressionParams configcompression.CompressionParams `js:"compression_params"`
+	// Encoding of the request body, only supported by the zipkin exporter: "json" or "proto" (default: "json")
+	Encoding string `js:"encoding"`
+	// Configuration of the file exporter
+	File FileConfig `js:"file"`
+	// Timeout of a single attempt to send traces, e.g. "10s", not supported by the file exporter (optional)
+	Timeout string `js:"timeout"`
+	// Retry configures the retries of failed pushes, not supported by the file exporter (optional)
+	Retry *RetryConfig `js:"retry"`
+	// Queue configures the sending queue, not supported by the file exporter (optional)
+	Queue *QueueConfig `js:"queue"`
+	// Tenants configures a rotation of tenants across pushes, not supported by the file exporter (optional)
+	Tenants *TenantConfig `js:"tenants"`
 }
 
 type Client struct {
-	exporter exporter.Traces
-	vu       modules.VU
+	exporter     exporter.Traces
+	exporterType exporterType
+	endpoint     string
+	vu           modules.VU
+	metrics      *tracingMetrics
+	tenants      *tenantRotation
+
+	shutdownOnce sync.Once
+	shutdownErr  error
 }
 
-func NewClient(cfg *ClientConfig, vu modules.VU) (*Client, error) {
+// NewClient creates a client that sends traces using the configured exporter. If m is not nil, a set of k6
+// samples is emitted for every push. The client is shut down once the context of the VU it was created in is
+// done, which is the end of the test for clients created in the init context.
+func NewClient(cfg *ClientConfig, vu modules.VU, m *tracingMetrics) (*Client, error) {
+	if cfg.Exporter == exporterNone {
+		cfg.Exporter = exporterOTLP
+	}
 	if cfg.Endpoint == "" {
-		cfg.Endpoint = "0.0.0.0:4317"
+		switch cfg.Exporter {
+		case exporterZipkin:
+			cfg.Endpoint = "localhost:9411"
+		case exporterJaeger:
+			cfg.Endpoint = "localhost:14250"
+		case exporterJaegerThriftHTTP:
+			cfg.Endpoint = "localhost:14268"
+		case exporterFile:
+			cfg.Endpoint = cfg.File.Path
+		default:
+			cfg.Endpoint = "0.0.0.0:4317"
+		}
 	}
 
 	var (
@@ -177,40 +378,117 @@ func NewClient(cfg *ClientConfig, vu modules.VU) (*Client, error) {
 		exporterCfg component.Config
 	)
 
-	tlsConfig := configtls.ClientConfig{
-		Insecure:           cfg.TLS.Insecure,
-		InsecureSkipVerify: cfg.TLS.InsecureSkipVerify,
-		ServerName:         cfg.TLS.ServerName,
-		Config: configtls.Config{
-			CAFile:   cfg.TLS.CAFile,
-			CertFile: cfg.TLS.CertFile,
-			KeyFile:  cfg.TLS.KeyFile,
-		},
+	tlsConfig := cfg.TLS.clientConfig()
+
+	auth, err := newClientAuth(&cfg.Authentication)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init exporter: invalid authentication: %w", err)
 	}
 
 	switch cfg.Exporter {
-	case exporterNone, exporterOTLP:
+	case exporterOTLP:
 		factory = otlpexporter.NewFactory()
 		exporterCfg = factory.CreateDefaultConfig()
-		exporterCfg.(*otlpexporter.Config).ClientConfig = configgrpc.ClientConfig{
+		otlpCfg := exporterCfg.(*otlpexporter.Config)
+		otlpCfg.ClientConfig = configgrpc.ClientConfig{
 			Endpoint:    cfg.Endpoint,
 			TLS:         tlsConfig,
-			Headers:     buildHeaders(cfg),
+			Headers:     buildHeaders(cfg, auth),
+			Auth:        auth.config(),
 			Compression: cfg.Compression,
+			Middlewares: headersMiddlewares(),
 		}
+		err = cfg.applySending(&otlpCfg.TimeoutConfig.Timeout, &otlpCfg.RetryConfig, &otlpCfg.QueueConfig)
 	case exporterOTLPHTTP:
 		factory = otlphttpexporter.NewFactory()
 		exporterCfg = factory.CreateDefaultConfig()
-		exporterCfg.(*otlphttpexporter.Config).ClientConfig = confighttp.ClientConfig{
+		otlpHTTPCfg := exporterCfg.(*otlphttpexporter.Config)
+		otlpHTTPCfg.ClientConfig = confighttp.ClientConfig{
+			Endpoint:          cfg.Endpoint,
+			TLS:               tlsConfig,
+			Headers:           buildHeaders(cfg, auth),
+			Auth:              auth.config(),
+			Compression:       cfg.Compression,
+			CompressionParams: cfg.CompressionParams,
+			Middlewares:       headersMiddlewares(),
+			Timeout:           otlpHTTPCfg.ClientConfig.Timeout,
+		}
+		err = cfg.applySending(&otlpHTTPCfg.ClientConfig.Timeout, &otlpHTTPCfg.RetryConfig, &otlpHTTPCfg.QueueConfig)
+	case exporterZipkin:
+		factory = zipkinexporter.NewFactory()
+		exporterCfg = factory.CreateDefaultConfig()
+		zipkinCfg := exporterCfg.(*zipkinexporter.Config)
+		zipkinCfg.ClientConfig = confighttp.ClientConfig{
 			Endpoint:          cfg.Endpoint,
 			TLS:               tlsConfig,
-			Headers:           buildHeaders(cfg),
+			Headers:           buildHeaders(cfg, auth),
+			Auth:              auth.config(),
 			Compression:       cfg.Compression,
 			CompressionParams: cfg.CompressionParams,
+			Middlewares:       headersMiddlewares(),
+			Timeout:           zipkinCfg.ClientConfig.Timeout,
+		}
+		if cfg.Encoding != "" {
+			zipkinCfg.Encoding = cfg.Encoding
+		}
+		err = cfg.applySending(&zipkinCfg.ClientConfig.Timeout, &zipkinCfg.RetryConfig, &zipkinCfg.QueueConfig)
+	case exporterJaeger:
+		factory = jaegerexporter.NewFactory()
+		exporterCfg = factory.CreateDefaultConfig()
+		jaegerCfg := exporterCfg.(*jaegerexporter.Config)
+		jaegerCfg.ClientConfig = configgrpc.ClientConfig{
+			Endpoint:    cfg.Endpoint,
+			TLS:         tlsConfig,
+			Headers:     buildHeaders(cfg, auth),
+			Auth:        auth.config(),
+			Compression: cfg.Compression,
+			Middlewares: headersMiddlewares(),
+		}
+		err = cfg.applySending(&jaegerCfg.TimeoutConfig.Timeout, &jaegerCfg.RetryConfig, &jaegerCfg.QueueConfig)
+	case exporterJaegerThriftHTTP:
+		factory = jaegerexporter.NewThriftHTTPFactory()
+		exporterCfg = factory.CreateDefaultConfig()
+		thriftCfg := exporterCfg.(*jaegerexporter.ThriftHTTPConfig)
+		thriftCfg.ClientConfig = confighttp.ClientConfig{
+			Endpoint:          cfg.Endpoint,
+			TLS:               tlsConfig,
+			Headers:           buildHeaders(cfg, auth),
+			Auth:              auth.config(),
+			Compression:       cfg.Compression,
+			CompressionParams: cfg.CompressionParams,
+			Middlewares:       headersMiddlewares(),
+			Timeout:           thriftCfg.ClientConfig.Timeout,
+		}
+		err = cfg.applySending(&thriftCfg.ClientConfig.Timeout, &thriftCfg.RetryConfig, &thriftCfg.QueueConfig)
+	case exporterFile:
+		if cfg.Timeout != "" || cfg.Retry != nil || cfg.Queue != nil {
+			return nil, errors.New("failed to init exporter: timeout, retry and queue are not supported by the file exporter")
+		}
+		factory = fileexporter.NewFactory()
+		exporterCfg = factory.CreateDefaultConfig()
+		fileCfg := exporterCfg.(*fileexporter.Config)
+		fileCfg.Path = cfg.File.Path
+		fileCfg.Compression = string(cfg.Compression)
+		if cfg.File.Format != "" {
+			fileCfg.Format = cfg.File.Format
+		}
+		if cfg.File.Rotation.MaxMegabytes > 0 {
+			fileCfg.Rotation = &fileexporter.Rotation{
+				MaxMegabytes: cfg.File.Rotation.MaxMegabytes,
+				MaxBackups:   cfg.File.Rotation.MaxBackups,
+			}
 		}
 	default:
 		return nil, fmt.Errorf("failed to init exporter: unknown exporter type %s", cfg.Exporter)
 	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to init exporter: %w", err)
+	}
+
+	tenants, err := newTenantRotation(cfg.Tenants, vu)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init exporter: %w", err)
+	}
 
 	exporter, err := factory.CreateTraces(
 		context.Background(),
@@ -229,32 +507,117 @@ func NewClient(cfg *ClientConfig, vu modules.VU) (*Client, error) {
 		return nil, fmt.Errorf("failed create exporter: %w", err)
 	}
 
-	err = exporter.Start(vu.Context(), componenttest.NewNopHost())
+	err = exporter.Start(vu.Context(), exporterHost{auth: auth})
 	if err != nil {
 		return nil, fmt.Errorf("failed to start exporter: %w", err)
 	}
 
-	return &Client{
-		exporter: exporter,
-		vu:       vu,
-	}, nil
+	client := &Client{
+		exporter:     exporter,
+		exporterType: cfg.Exporter,
+		endpoint:     cfg.Endpoint,
+		vu:           vu,
+		metrics:      m,
+		tenants:      tenants,
+	}
+	context.AfterFunc(vu.Context(), func() {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		_ = client.shutdown(ctx)
+	})
+
+	return client, nil
+}
+
+// Push sends the traces and blocks until they were sent. The optional opts contain headers for this push.
+func (c *Client) Push(traces *Traces, opts *PushOptions) error {
+	sink := c.sampleSink()
+	stats := c.push(sink.ctx, traces.traces, c.pushHeaders(opts))
+	c.emitMetrics(sink, stats)
+	return stats.err
 }
 
-func (c *Client) Push(traces ptrace.Traces) error {
-	return c.exporter.ConsumeTraces(c.vu.Context(), traces)
+// PushAsync sends the traces without blocking the VU. The returned promise is resolved once the traces were sent
+// or rejected with the error of the push. The traces must not be modified until the promise is settled.
+func (c *Client) PushAsync(traces *Traces, opts *PushOptions) *sobek.Promise {
+	promise, resolve, reject := promises.New(c.vu)
+	// the VU must not be used by the goroutine, so the tags, headers and samples channel are determined on the
+	// event loop when the push is started
+	sink := c.sampleSink()
+	headers := c.pushHeaders(opts)
+
+	go func() {
+		stats := c.push(sink.ctx, traces.traces, headers)
+		c.emitMetrics(sink, stats)
+		if stats.err != nil {
+			reject(stats.err)
+			return
+		}
+		resolve(sobek.Undefined())
+	}()
+
+	return promise
+}
+
+func (c *Client) push(ctx context.Context, traces ptrace.Traces, headers map[string][]string) pushStats {
+	stats := newPushStats(traces)
+	stats.start = time.Now()
+	stats.err = c.exporter.ConsumeTraces(contextWithHeaders(ctx, headers), traces)
+	stats.duration = time.Since(stats.start)
+	return stats
+}
+
+// sampleSink is where the samples of a push are sent to. It is taken from the VU on the event loop, so that
+// asynchronous pushes don't access the VU.
+type sampleSink struct {
+	ctx     context.Context
+	samples chan<- metrics.SampleContainer
+	// tags is nil if the push is not recorded
+	tags *metrics.TagsAndMeta
+}
+
+// sampleSink returns the context of the VU and the tags and channel for the samples of a push. Pushes outside a
+// VU context, e.g. during init, are not recorded.
+func (c *Client) sampleSink() sampleSink {
+	sink := sampleSink{ctx: c.vu.Context()}
+	state := c.vu.State()
+	if c.metrics == nil || state == nil {
+		return sink
+	}
+
+	tags := state.Tags.GetCurrentValues()
+	tags.SetTag(tagExporter, string(c.exporterType))
+	tags.SetTag(tagEndpoint, c.endpoint)
+	sink.samples, sink.tags = state.Samples, &tags
+	return sink
+}
+
+// emitMetrics sends the samples for a push to k6.
+func (c *Client) emitMetrics(sink sampleSink, stats pushStats) {
+	if sink.tags == nil {
+		return
+	}
+	metrics.PushIfNotDone(sink.ctx, sink.samples, c.metrics.samples(stats, sink.tags))
 }
 
+// Shutdown flushes queued traces and stops the exporter. Clients are shut down automatically at the end of the
+// test, calling Shutdown is only required to flush the traces earlier. Calling Shutdown more than once has no
+// effect.
 func (c *Client) Shutdown() error {
-	return c.exporter.Shutdown(c.vu.Context())
+	return c.shutdown(c.vu.Context())
 }
 
-func authorizationHeader(user, password string) configopaque.String {
-	return configopaque.String("Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password)))
+func (c *Client) shutdown(ctx context.Context) error {
+	c.shutdownOnce.Do(func() {
+		c.shutdownErr = c.exporter.Shutdown(ctx)
+	})
+	return c.shutdownErr
 }
 
-func buildHeaders(cfg *ClientConfig) configopaque.MapList {
-	headers := configopaque.MapList{
-		{Name: "Authorization", Value: authorizationHeader(cfg.Authentication.User, cfg.Authentication.Password)},
+func buildHeaders(cfg *ClientConfig, auth *clientAuth) configopaque.MapList {
+	var headers configopaque.MapList
+	if auth.header != "" {
+		headers = append(headers, configopaque.Pair{Name: "Authorization", Value: auth.header})
 	}
 	for name, value := range cfg.Headers {
 		headers = append(headers, configopaque.Pair{Name: name, Value: value})
@@ -0,0 +1,169 @@
+package clienttracing
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/v2/js/modulestest"
+	"go.k6.io/k6/v2/lib"
+	"go.k6.io/k6/v2/metrics"
+
+	"github.com/grafana/xk6-client-tracing/pkg/tracegen"
+)
+
+func TestClient_PushAsync(t *testing.T) {
+	runtime := modulestest.NewRuntime(t)
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	client, err := NewClient(&ClientConfig{Exporter: exporterFile, File: FileConfig{Path: path}}, runtime.VU, nil)
+	require.NoError(t, err)
+
+	gen := tracegen.NewParameterizedGenerator([]*tracegen.TraceParams{{Count: 1, Spans: tracegen.SpanParams{Count: 2}}}, nil)
+	rt := runtime.VU.Runtime()
+	require.NoError(t, rt.Set("client", client))
+	require.NoError(t, rt.Set("gen", &generator{generator: gen}))
+
+	_, err = runtime.RunOnEventLoop(`
+		var settled = "pending";
+		Promise.all([client.pushAsync(gen.traces()), client.pushAsync(gen.traces())])
+			.then(() => { settled = "resolved"; }, (err) => { settled = "rejected: " + err; });
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, "resolved", rt.Get("settled").String())
+	require.NoError(t, client.Shutdown())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var lines int
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		lines++
+	}
+	require.NoError(t, scanner.Err())
+	assert.Equal(t, 2, lines)
+}
+
+func TestNewClient_FileSending(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	for name, cfg := range map[string]ClientConfig{
+		"timeout": {Timeout: "1s"},
+		"retry":   {Retry: &RetryConfig{}},
+		"queue":   {Queue: &QueueConfig{}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg.Exporter, cfg.File.Path = exporterFile, path
+			_, err := NewClient(&cfg, modulestest.NewRuntime(t).VU, nil)
+			assert.ErrorContains(t, err, "timeout, retry and queue are not supported by the file exporter")
+		})
+	}
+}
+
+func TestClient_PushAsyncMetrics(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	runtime := modulestest.NewRuntime(t)
+	registry := runtime.VU.InitEnv().Registry
+	client, err := NewClient(&ClientConfig{Exporter: exporterOTLPHTTP, Endpoint: server.URL}, runtime.VU, registerMetrics(registry))
+	require.NoError(t, err)
+
+	samples := make(chan metrics.SampleContainer, 1)
+	runtime.MoveToVUContext(&lib.State{Samples: samples, Tags: lib.NewVUStateTags(registry.RootTagSet())})
+	gen := tracegen.NewParameterizedGenerator([]*tracegen.TraceParams{{Count: 1, Spans: tracegen.SpanParams{Count: 2}}}, nil)
+	rt := runtime.VU.Runtime()
+	require.NoError(t, rt.Set("client", client))
+	require.NoError(t, rt.Set("gen", &generator{generator: gen}))
+	_, err = rt.RunString(`client.pushAsync(gen.traces())`)
+	require.NoError(t, err)
+
+	// the push outlives the iteration, its samples are still sent to the channel of the iteration
+	runtime.VU.StateField = nil
+	close(release)
+	select {
+	case container := <-samples:
+		assert.Equal(t, float64(2), sampleValues(container.GetSamples())[metricSpansSent])
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "no samples emitted")
+	}
+	runtime.EventLoop.WaitOnRegistered()
+}
+
+func TestTracingModule_Clients(t *testing.T) {
+	runtime := modulestest.NewRuntime(t)
+	rt := runtime.VU.Runtime()
+	mi := new(RootModule).NewModuleInstance(runtime.VU)
+	require.NoError(t, rt.Set("tracing", mi.Exports().Named))
+	dir := t.TempDir()
+	require.NoError(t, rt.Set("dir", dir))
+
+	val, err := rt.RunString(`
+		const gen = new tracing.ParameterizedGenerator([{count: 1, spans: {count: 1}}]);
+		const first = new tracing.Client({exporter: tracing.EXPORTER_FILE, file: {path: dir + "/first.jsonl"}, compression: "gzip"});
+		const second = new tracing.Client({exporter: tracing.EXPORTER_FILE, file: {path: dir + "/second.jsonl"}, compression: "gzip"});
+		first.push(gen.traces());
+		second.push(gen.traces());
+		second.push(gen.traces());
+		first !== second;
+	`)
+	require.NoError(t, err)
+	assert.True(t, val.ToBoolean())
+
+	// the clients are shut down and the gzip streams are completed once the VU is done
+	runtime.CancelContext()
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, 1, countGzipLines(c, filepath.Join(dir, "first.jsonl")))
+		assert.Equal(c, 2, countGzipLines(c, filepath.Join(dir, "second.jsonl")))
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestTracingModule_TopologyGenerator(t *testing.T) {
+	runtime := modulestest.NewRuntime(t)
+	rt := runtime.VU.Runtime()
+	mi := new(RootModule).NewModuleInstance(runtime.VU)
+	require.NoError(t, rt.Set("tracing", mi.Exports().Named))
+
+	val, err := rt.RunString(`
+		const gen = new tracing.TopologyGenerator({
+			services: [
+				{name: "frontend", operations: [{name: "GET /articles", calls: [{service: "article-service", repeat: {min: 2, max: 2}}]}]},
+				{name: "article-service", operations: [{name: "get-article", duration: {type: tracing.DISTRIBUTION_FIXED, value: 10}}]},
+			],
+		}, {seed: 1});
+		const traces = gen.traces();
+		[
+			traces.spanCount() === 5,
+			traces.services().join() === "article-service,frontend",
+		].every((ok) => ok);
+	`)
+	require.NoError(t, err)
+	assert.True(t, val.ToBoolean())
+
+	_, err = rt.RunString(`new tracing.TopologyGenerator({services: [{name: "frontend"}]})`)
+	assert.ErrorContains(t, err, "service frontend must have at least one operation")
+}
+
+func countGzipLines(c *assert.CollectT, path string) int {
+	data, err := os.ReadFile(path)
+	require.NoError(c, err)
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(c, err)
+	data, err = io.ReadAll(gz)
+	require.NoError(c, err)
+	return bytes.Count(data, []byte("\n"))
+}
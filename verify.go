@@ -0,0 +1,114 @@
+package clienttracing
+
+import (
+	"fmt"
+	"slices"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// traceSpans contains the spans of a single trace by span id.
+type traceSpans struct {
+	id    pcommon.TraceID
+	spans map[pcommon.SpanID]spanRef
+	// order contains the span ids in the order of the traces, so that mismatches are reported deterministically
+	order []pcommon.SpanID
+}
+
+type spanRef struct {
+	span     ptrace.Span
+	resource pcommon.Resource
+}
+
+// splitTraces groups the spans of traces by trace id, the traces are returned in the order they first appear.
+func splitTraces(traces ptrace.Traces) []*traceSpans {
+	var result []*traceSpans
+	byID := make(map[pcommon.TraceID]*traceSpans)
+	for i := 0; i < traces.ResourceSpans().Len(); i++ {
+		rs := traces.ResourceSpans().At(i)
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			ss := rs.ScopeSpans().At(j)
+			for k := 0; k < ss.Spans().Len(); k++ {
+				span := ss.Spans().At(k)
+				ts, ok := byID[span.TraceID()]
+				if !ok {
+					ts = &traceSpans{id: span.TraceID(), spans: make(map[pcommon.SpanID]spanRef)}
+					byID[ts.id] = ts
+					result = append(result, ts)
+				}
+				if _, ok := ts.spans[span.SpanID()]; !ok {
+					ts.order = append(ts.order, span.SpanID())
+				}
+				ts.spans[span.SpanID()] = spanRef{span: span, resource: rs.Resource()}
+			}
+		}
+	}
+	return result
+}
+
+// compareTrace compares a pushed trace with the trace returned by a query API. The span count, the structure of
+// the trace as well as the names, kinds, status codes and attributes of the spans and their resources must match.
+// Attributes added by the backend are ignored.
+func compareTrace(expected *traceSpans, actual ptrace.Traces) []string {
+	actualSpans := &traceSpans{spans: map[pcommon.SpanID]spanRef{}}
+	for _, ts := range splitTraces(actual) {
+		if ts.id == expected.id {
+			actualSpans = ts
+		}
+	}
+
+	prefix := "trace " + expected.id.String()
+	var mismatches []string
+	if len(expected.spans) != len(actualSpans.spans) {
+		mismatches = append(mismatches, fmt.Sprintf("%s: expected %d spans, got %d", prefix, len(expected.spans), len(actualSpans.spans)))
+	}
+
+	for _, spanID := range expected.order {
+		exp := expected.spans[spanID]
+		act, ok := actualSpans.spans[spanID]
+		spanPrefix := fmt.Sprintf("%s: span %s", prefix, spanID)
+		if !ok {
+			mismatches = append(mismatches, fmt.Sprintf("%s (%s) is missing", spanPrefix, exp.span.Name()))
+			continue
+		}
+
+		if exp.span.Name() != act.span.Name() {
+			mismatches = append(mismatches, fmt.Sprintf("%s: expected name %q, got %q", spanPrefix, exp.span.Name(), act.span.Name()))
+		}
+		if exp.span.ParentSpanID() != act.span.ParentSpanID() {
+			mismatches = append(mismatches, fmt.Sprintf("%s: expected parent %s, got %s", spanPrefix, exp.span.ParentSpanID(), act.span.ParentSpanID()))
+		}
+		if exp.span.Kind() != act.span.Kind() {
+			mismatches = append(mismatches, fmt.Sprintf("%s: expected kind %s, got %s", spanPrefix, exp.span.Kind(), act.span.Kind()))
+		}
+		if exp.span.Status().Code() != act.span.Status().Code() {
+			mismatches = append(mismatches, fmt.Sprintf("%s: expected status %s, got %s", spanPrefix, exp.span.Status().Code(), act.span.Status().Code()))
+		}
+		mismatches = append(mismatches, compareAttributes(spanPrefix+": attribute", exp.span.Attributes(), act.span.Attributes())...)
+		mismatches = append(mismatches, compareAttributes(spanPrefix+": resource attribute", exp.resource.Attributes(), act.resource.Attributes())...)
+	}
+	return mismatches
+}
+
+// compareAttributes returns a mismatch for every attribute in expected that is missing or different in actual.
+func compareAttributes(prefix string, expected, actual pcommon.Map) []string {
+	keys := make([]string, 0, expected.Len())
+	for k := range expected.All() {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	var mismatches []string
+	for _, k := range keys {
+		exp, _ := expected.Get(k)
+		act, ok := actual.Get(k)
+		switch {
+		case !ok:
+			mismatches = append(mismatches, fmt.Sprintf("%s %s is missing", prefix, k))
+		case !exp.Equal(act):
+			mismatches = append(mismatches, fmt.Sprintf("%s %s: expected %q, got %q", prefix, k, exp.AsString(), act.AsString()))
+		}
+	}
+	return mismatches
+}